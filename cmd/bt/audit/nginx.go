@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Vhost 从nginx配置文件中解析出的关键信息
+type Vhost struct {
+	ServerNames []string
+	Root        string
+	Listens     []string
+	PHPVersion  string
+	SSL         bool
+	Certificate string
+}
+
+var phpIncludePattern = regexp.MustCompile(`enable-php-(\d+)\.conf`)
+
+// parseVhost 解析nginx配置，忽略注释，按分号拆分指令
+func parseVhost(conf string) Vhost {
+	vhost := Vhost{}
+
+	for _, line := range strings.Split(conf, "\n") {
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+
+		for _, statement := range strings.Split(line, ";") {
+			fields := strings.Fields(strings.Trim(strings.TrimSpace(statement), "{}"))
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch fields[0] {
+			case "server_name":
+				for _, name := range fields[1:] {
+					if !contains(vhost.ServerNames, name) {
+						vhost.ServerNames = append(vhost.ServerNames, name)
+					}
+				}
+			case "root":
+				if len(fields) > 1 && vhost.Root == "" {
+					vhost.Root = fields[1]
+				}
+			case "listen":
+				vhost.Listens = append(vhost.Listens, strings.Join(fields[1:], " "))
+				if contains(fields[1:], "ssl") {
+					vhost.SSL = true
+				}
+			case "include":
+				if len(fields) > 1 {
+					if match := phpIncludePattern.FindStringSubmatch(fields[1]); match != nil {
+						vhost.PHPVersion = match[1]
+					}
+				}
+			case "ssl_certificate":
+				vhost.SSL = true
+				if len(fields) > 1 {
+					vhost.Certificate = fields[1]
+				}
+			}
+		}
+	}
+
+	return vhost
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// directivePattern 匹配行首的指令，不匹配注释掉的指令
+func directivePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^([ \t]*)` + name + `\b[^;\n]*;[^\n]*\n?`)
+}
+
+var phpIncludeDirective = regexp.MustCompile(`(?m)^([ \t]*include[ \t]+)enable-php-\d+\.conf;`)
+
+// patchVhost 只修改与面板记录不一致的 server_name、listen、root 及PHP引用，保留其他自定义的指令
+func patchVhost(conf string, vhost Vhost, domains []string, ports []string, root string, phpVersion string) string {
+	serverName := directivePattern("server_name")
+	if len(domains) > 0 && !sameSet(vhost.ServerNames, domains) {
+		conf = serverName.ReplaceAllString(conf, "${1}server_name "+strings.Join(domains, " ")+";\n")
+	}
+
+	var missing []string
+	for _, port := range ports {
+		if !listensOn(vhost.Listens, port) {
+			missing = append(missing, port)
+		}
+	}
+	if len(missing) > 0 {
+		// 新的 listen 放在第一条 listen 之前，没有 listen 时放在 server_name 之前
+		anchor := directivePattern("listen").FindStringSubmatchIndex(conf)
+		if anchor == nil {
+			anchor = serverName.FindStringSubmatchIndex(conf)
+		}
+		if anchor != nil {
+			indent := conf[anchor[2]:anchor[3]]
+			lines := ""
+			for _, port := range missing {
+				lines += indent + "listen " + port + ";\n"
+			}
+			conf = conf[:anchor[0]] + lines + conf[anchor[0]:]
+		}
+	}
+
+	rootDirective := directivePattern("root")
+	if vhost.Root != root {
+		if match := rootDirective.FindStringSubmatchIndex(conf); match != nil {
+			conf = conf[:match[0]] + conf[match[2]:match[3]] + "root " + root + ";\n" + conf[match[1]:]
+		} else if match := serverName.FindStringSubmatchIndex(conf); match != nil {
+			conf = conf[:match[1]] + conf[match[2]:match[3]] + "root " + root + ";\n" + conf[match[1]:]
+		}
+	}
+
+	if phpVersion != "" && vhost.PHPVersion != phpVersion {
+		include := "enable-php-" + phpVersion + ".conf;"
+		if phpIncludeDirective.MatchString(conf) {
+			conf = phpIncludeDirective.ReplaceAllString(conf, "${1}"+include)
+		} else if match := rootDirective.FindStringSubmatchIndex(conf); match != nil {
+			conf = conf[:match[1]] + conf[match[2]:match[3]] + "include " + include + "\n" + conf[match[1]:]
+		}
+	}
+
+	return conf
+}
+
+// sameSet 判断两个列表包含的元素是否相同
+func sameSet(a []string, b []string) bool {
+	for _, item := range a {
+		if !contains(b, item) {
+			return false
+		}
+	}
+	for _, item := range b {
+		if !contains(a, item) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/site"
+	"jarvis/cmd/bt/utils"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+const vhostDir = "/www/server/panel/vhost/nginx/"
+
+var AuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: color.Blue.Render("检查网站记录与配置文件是否一致"),
+	Long:  color.Success.Render("\r\n对比宝塔的网站记录与nginx配置文件，报告缺失的配置、孤立的配置、不存在的根目录以及不一致的域名"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		fix, _ := cmd.Flags().GetBool("fix")
+		patch, _ := cmd.Flags().GetBool("patch")
+
+		sites, err := site.Get(host, key)
+		if err != nil {
+			return err
+		}
+
+		problems := 0
+		names := map[string]bool{}
+		for _, item := range sites {
+			names[item.Name+".conf"] = true
+
+			result, err := auditSite(host, key, item)
+			if err != nil {
+				return err
+			}
+			if len(result.Issues) == 0 {
+				color.Infoln("✅ " + item.Name)
+				continue
+			}
+
+			problems++
+			color.Warnln("❌ " + item.Name)
+			for _, issue := range result.Issues {
+				color.Warnln("   - " + issue)
+			}
+
+			if fix {
+				if message, err := fixSite(host, key, item, result, patch); err != nil {
+					color.Errorln("   修复失败：", err)
+				} else {
+					color.Infoln("   " + message)
+				}
+			}
+		}
+
+		files, _, err := utils.ListDir(host, key, vhostDir)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".conf") || names[file] || isPanelConf(file) {
+				continue
+			}
+			problems++
+			color.Warnln("❌ 孤立的配置文件：" + vhostDir + file)
+		}
+
+		fmt.Println()
+		if problems == 0 {
+			color.Success.Println("没有发现问题")
+		} else {
+			color.Warnln(fmt.Sprintf("发现 %d 处问题", problems))
+		}
+
+		return nil
+	},
+}
+
+// auditResult 单个网站的检查结果
+type auditResult struct {
+	Exists bool
+	Conf   string
+	Vhost  Vhost
+	// SSL 面板中网站是否开启了SSL
+	SSL     bool
+	Domains []string
+	Ports   []string
+	PHP     string
+	Issues  []string
+}
+
+// auditSite 检查单个网站的配置文件
+func auditSite(host string, key string, item site.SiteItem) (auditResult, error) {
+	result := auditResult{}
+
+	domains, err := site.Domains(host, key, item.Id)
+	if err != nil {
+		return result, err
+	}
+	for _, domain := range domains {
+		result.Domains = append(result.Domains, domain.Name)
+		if port := fmt.Sprint(domain.Port); !contains(result.Ports, port) {
+			result.Ports = append(result.Ports, port)
+		}
+	}
+
+	result.PHP, err = site.PHPVersion(host, key, item.Name)
+	if err != nil {
+		return result, err
+	}
+
+	ssl, err := site.SSL(host, key, item.Name)
+	if err != nil {
+		return result, err
+	}
+	result.SSL = ssl.Status
+
+	conf, err := utils.ReadFile(host, key, vhostDir+item.Name+".conf")
+	if err != nil {
+		result.Issues = append(result.Issues, "配置文件不存在："+vhostDir+item.Name+".conf")
+		return result, nil
+	}
+	result.Exists = true
+	result.Conf = conf
+	result.Vhost = parseVhost(conf)
+	vhost := result.Vhost
+
+	for _, domain := range result.Domains {
+		if !contains(vhost.ServerNames, domain) {
+			result.Issues = append(result.Issues, "server_name 缺少面板中的域名："+domain)
+		}
+	}
+	for _, name := range vhost.ServerNames {
+		if !contains(result.Domains, name) {
+			result.Issues = append(result.Issues, "server_name 包含面板中没有的域名："+name)
+		}
+	}
+
+	for _, port := range result.Ports {
+		if !listensOn(vhost.Listens, port) {
+			result.Issues = append(result.Issues, "没有监听面板中的端口："+port)
+		}
+	}
+
+	switch {
+	case vhost.Root == "":
+		result.Issues = append(result.Issues, "没有配置 root")
+	case !underPath(vhost.Root, item.Path):
+		result.Issues = append(result.Issues, fmt.Sprintf("root %s 与面板中的路径 %s 不一致", vhost.Root, item.Path))
+	}
+	if vhost.Root != "" {
+		if _, _, err := utils.ListDir(host, key, vhost.Root); err != nil {
+			result.Issues = append(result.Issues, "根目录不存在："+vhost.Root)
+		}
+	}
+
+	if vhost.PHPVersion != result.PHP {
+		result.Issues = append(result.Issues, fmt.Sprintf("PHP版本 %s 与面板中的 %s 不一致", vhost.PHPVersion, result.PHP))
+	}
+
+	if result.SSL != vhost.SSL {
+		if result.SSL {
+			result.Issues = append(result.Issues, "面板中已开启SSL，配置文件中没有SSL配置")
+		} else {
+			result.Issues = append(result.Issues, "配置文件中有SSL配置，面板中没有开启SSL")
+		}
+	}
+	if vhost.SSL && vhost.Certificate != "" {
+		if _, err := utils.ReadFile(host, key, vhost.Certificate); err != nil {
+			result.Issues = append(result.Issues, "SSL证书不存在："+vhost.Certificate)
+		}
+	}
+
+	return result, nil
+}
+
+// fixSite 按模板重新生成配置文件，patch 为 true 且配置文件存在时只修正不一致的指令，
+// 原文件备份为 .bak，返回修复的说明
+func fixSite(host string, key string, item site.SiteItem, result auditResult, patch bool) (string, error) {
+	path := vhostDir + item.Name + ".conf"
+
+	root := result.Vhost.Root
+	if root == "" || !underPath(root, item.Path) {
+		root = item.Path
+	}
+
+	message := "已按模板重新生成配置文件"
+	var content string
+	if patch && result.Exists {
+		content = patchVhost(result.Conf, result.Vhost, result.Domains, result.Ports, root, result.PHP)
+		if content == result.Conf {
+			return "", errors.New("没有可以自动修正的指令，请手动处理或去掉 --patch 按模板重新生成")
+		}
+		message = "已修正不一致的指令"
+	} else {
+		var err error
+		content, err = renderVhost(item.Name, result.Domains, result.Ports, root, result.PHP, result.SSL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if !result.Exists {
+		if err := utils.CreateFile(host, key, path); err != nil {
+			return "", err
+		}
+		return message, utils.SaveFile(host, key, path, content)
+	}
+
+	if err := utils.CopyFile(host, key, path, path+".bak"); err != nil {
+		return "", err
+	}
+
+	return message + "，原文件备份为 " + path + ".bak", utils.SaveFile(host, key, path, content)
+}
+
+// underPath 判断 root 是否为 path 或其子目录，按完整的路径段比较
+func underPath(root string, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	root = strings.TrimSuffix(root, "/")
+
+	return root == path || strings.HasPrefix(root, path+"/")
+}
+
+// listensOn 判断listen指令中是否包含端口
+func listensOn(listens []string, port string) bool {
+	for _, listen := range listens {
+		address := strings.Fields(listen)
+		if len(address) == 0 {
+			continue
+		}
+		if address[0] == port || strings.HasSuffix(address[0], ":"+port) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// panelConfPrefixes 宝塔自带的配置文件，及 Node、Java、Go、Python 等项目的配置文件，
+// 项目不在网站列表中
+var panelConfPrefixes = []string{"0.", "phpfpm_status", "node_", "java_", "go_", "python_", "net_", "other_", "html_"}
+
+// isPanelConf 判断配置文件是否不属于PHP网站
+func isPanelConf(file string) bool {
+	for _, prefix := range panelConfPrefixes {
+		if strings.HasPrefix(file, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	AuditCmd.Flags().Bool("fix", false, color.Blue.Render("按模板重新生成有问题的配置文件，原文件备份为 .bak"))
+	AuditCmd.Flags().Bool("patch", false, color.Blue.Render("与 --fix 一起使用，只修正不一致的 server_name、listen、root 及PHP引用，保留自定义的指令"))
+}
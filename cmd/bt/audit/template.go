@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// vhostTemplate 宝塔默认的PHP网站配置，与 sample.conf 保持一致
+var vhostTemplate = template.Must(template.New("vhost").Parse(`server
+{
+{{- range .Ports}}
+    listen {{.}};
+{{- end}}
+{{- if .SSL}}
+    listen 443 ssl http2;
+{{- end}}
+    server_name {{.ServerNames}};
+    index index.php index.html index.htm default.php default.htm default.html;
+    root {{.Root}};
+
+    #SSL-START SSL相关配置，请勿删除或修改下一行带注释的404规则
+    #error_page 404/404.html;
+{{- if .SSL}}
+    ssl_certificate    /www/server/panel/vhost/cert/{{.Name}}/fullchain.pem;
+    ssl_certificate_key    /www/server/panel/vhost/cert/{{.Name}}/privkey.pem;
+    ssl_protocols TLSv1.1 TLSv1.2 TLSv1.3;
+    ssl_prefer_server_ciphers on;
+    ssl_session_cache shared:SSL:10m;
+    ssl_session_timeout 10m;
+    add_header Strict-Transport-Security "max-age=31536000";
+    error_page 497  https://$host$request_uri;
+{{- end}}
+    #SSL-END
+
+    #ERROR-PAGE-START  错误页配置，可以注释、删除或修改
+    #error_page 404 /404.html;
+    #error_page 502 /502.html;
+    #ERROR-PAGE-END
+
+    #PHP-INFO-START  PHP引用配置，可以注释或修改
+    include enable-php-{{.PHPVersion}}.conf;
+    #PHP-INFO-END
+
+    #REWRITE-START URL重写规则引用,修改后将导致面板设置的伪静态规则失效
+    include /www/server/panel/vhost/rewrite/{{.Name}}.conf;
+    #REWRITE-END
+
+    #禁止访问的文件或目录
+    location ~ ^/(\.user.ini|\.htaccess|\.git|\.svn|\.project|LICENSE|README.md)
+    {
+        return 404;
+    }
+
+    #一键申请SSL证书验证目录相关设置
+    location ~ \.well-known{
+        allow all;
+    }
+
+    location ~ .*\.(gif|jpg|jpeg|png|bmp|swf)$
+    {
+        expires      30d;
+        error_log /dev/null;
+        access_log /dev/null;
+    }
+
+    location ~ .*\.(js|css)?$
+    {
+        expires      12h;
+        error_log /dev/null;
+        access_log /dev/null;
+    }
+    access_log  /www/wwwlogs/{{.Name}}.log;
+    error_log  /www/wwwlogs/{{.Name}}.error.log;
+}
+`))
+
+// renderVhost 按模板生成网站配置
+func renderVhost(name string, domains []string, ports []string, root string, phpVersion string, ssl bool) (string, error) {
+	if len(ports) == 0 {
+		ports = []string{"80"}
+	}
+
+	var out bytes.Buffer
+	err := vhostTemplate.Execute(&out, map[string]interface{}{
+		"Name":        name,
+		"ServerNames": strings.Join(domains, " "),
+		"Ports":       ports,
+		"Root":        root,
+		"PHPVersion":  phpVersion,
+		"SSL":         ssl,
+	})
+
+	return out.String(), err
+}
@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/site"
+	"jarvis/cmd/bt/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// target 备份对象，网站或数据库
+type target struct {
+	Type int
+	Id   int
+	Name string
+}
+
+func (t target) label() string {
+	if t.Type == utils.BackupSite {
+		return "网站 " + t.Name
+	}
+	return "数据库 " + t.Name
+}
+
+// resolveTargets 根据 --site、--db、--with-db 确定备份对象
+func resolveTargets(cmd *cobra.Command) ([]target, error) {
+	host, _ := cmd.Flags().GetString("host")
+	key, _ := cmd.Flags().GetString("key")
+	siteName, _ := cmd.Flags().GetString("site")
+	dbName, _ := cmd.Flags().GetString("db")
+	withDb, _ := cmd.Flags().GetBool("with-db")
+
+	var targets []target
+
+	if siteName != "" {
+		item, err := site.Find(host, key, siteName)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{Type: utils.BackupSite, Id: item.Id, Name: item.Name})
+
+		if withDb && dbName == "" {
+			related, err := database.FindBySite(host, key, item.Id)
+			if err != nil {
+				return nil, err
+			}
+			if len(related) == 0 {
+				return nil, errors.New("网站没有关联的数据库，请通过 --db 指定")
+			}
+			for _, db := range related {
+				targets = append(targets, target{Type: utils.BackupDatabase, Id: db.Id, Name: db.Name})
+			}
+		}
+	}
+
+	if dbName != "" {
+		db, err := database.Find(host, key, dbName)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{Type: utils.BackupDatabase, Id: db.Id, Name: db.Name})
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("请通过 --site 或 --db 指定备份对象")
+	}
+
+	return targets, nil
+}
+
+// listBackups 获取备份对象的备份列表，按时间从新到旧排序
+func listBackups(host string, key string, t target) ([]utils.BackupItem, error) {
+	return utils.ListBackups(host, key, t.Type, t.Id)
+}
+
+// applyRetention 计算保留策略：保留最近N天每天最新的一份、最近N周每周最新的一份，items需按时间从新到旧排序
+func applyRetention(items []utils.BackupItem, daily int, weekly int) (keep []utils.BackupItem, remove []utils.BackupItem) {
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+
+	for _, item := range items {
+		t := item.Time()
+		day := t.Format("2006-01-02")
+		year, week := t.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+
+		kept := false
+		if !days[day] && len(days) < daily {
+			days[day] = true
+			kept = true
+		}
+		if !weeks[weekKey] && len(weeks) < weekly {
+			weeks[weekKey] = true
+			kept = true
+		}
+
+		if kept {
+			keep = append(keep, item)
+		} else {
+			remove = append(remove, item)
+		}
+	}
+
+	return keep, remove
+}
@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"jarvis/cmd/bt/utils"
+	"jarvis/cmd/shared"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var download = &cobra.Command{
+	Use:   "download",
+	Short: "下载备份到本地",
+	Long:  color.Success.Render("\r\n下载备份到本地目录，校验文件大小与宝塔上的文件一致，gzip压缩包同时校验CRC。\r\n本地已有 .sha256 文件时校验和需与之一致"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		dir, _ := cmd.Flags().GetString("dir")
+		all, _ := cmd.Flags().GetBool("all")
+
+		targets, err := resolveTargets(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		for _, t := range targets {
+			items, err := listBackups(host, key, t)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				color.Warnln(t.label() + "没有备份")
+				continue
+			}
+			if !all {
+				items = items[:1]
+			}
+
+			for _, item := range items {
+				if err := downloadBackup(host, key, item, dir); err != nil {
+					return fmt.Errorf("下载 %s 失败：%s", item.Filename, err)
+				}
+			}
+		}
+
+		return nil
+	},
+}
+
+// downloadBackup 下载单个备份，大小需与宝塔上的文件一致，gzip压缩包校验CRC，
+// 已有 sha256 文件时与之比对，完成后写入sha256文件
+func downloadBackup(host string, key string, item utils.BackupItem, dir string) error {
+	path := filepath.Join(dir, filepath.Base(item.Filename))
+	color.Blueln("下载：" + item.Filename)
+
+	// 以宝塔上文件的实际大小为准，备份记录中的大小可能为0
+	expected, err := utils.FileSize(host, key, item.Filename)
+	if err != nil {
+		return err
+	}
+	if expected < 0 {
+		return fmt.Errorf("宝塔上的备份文件不存在：%s", item.Filename)
+	}
+
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return err
+	}
+
+	link := host + "/download?filename=" + url.QueryEscape(item.Filename)
+	hash := sha256.New()
+	size, err := utils.Download(link, utils.PatchSign(key, url.Values{}), io.MultiWriter(file, hash))
+	file.Close()
+	if err != nil {
+		os.Remove(path + ".part")
+		return err
+	}
+
+	if size != expected {
+		os.Remove(path + ".part")
+		return fmt.Errorf("文件大小不一致，宝塔上为 %d，实际下载 %d", expected, size)
+	}
+
+	if strings.HasSuffix(item.Filename, ".gz") {
+		if err := utils.VerifyGzip(path + ".part"); err != nil {
+			os.Remove(path + ".part")
+			return fmt.Errorf("压缩包校验失败：%s", err)
+		}
+	}
+
+	sum := fmt.Sprintf("%x", hash.Sum(nil))
+	if previous, err := os.ReadFile(path + ".sha256"); err == nil {
+		if fields := strings.Fields(string(previous)); len(fields) > 0 && fields[0] != sum {
+			os.Remove(path + ".part")
+			return fmt.Errorf("校验和与 %s 中记录的不一致", path+".sha256")
+		}
+	}
+
+	if err := os.Rename(path+".part", path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".sha256", []byte(sum+"  "+filepath.Base(path)+"\n"), 0644); err != nil {
+		return err
+	}
+
+	color.Infoln("已保存：", path, shared.FormatBytes(size), "sha256:"+sum)
+
+	return nil
+}
+
+func init() {
+	download.Flags().String("site", "", color.Blue.Render("网站名称"))
+	download.Flags().String("db", "", color.Blue.Render("数据库名称，默认使用网站关联的数据库"))
+	download.Flags().Bool("with-db", false, color.Blue.Render("同时下载网站关联数据库的备份"))
+	download.Flags().StringP("dir", "d", "./backups", color.Blue.Render("保存目录"))
+	download.Flags().Bool("all", false, color.Blue.Render("下载全部备份，默认只下载最新的一份"))
+}
@@ -0,0 +1,39 @@
+package backup
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "展示备份列表",
+	Long:  color.Success.Render("\r\n展示网站及数据库的备份列表"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+
+		targets, err := resolveTargets(cmd)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range targets {
+			items, err := listBackups(host, key, t)
+			if err != nil {
+				return err
+			}
+
+			color.Blueln(t.label() + "：")
+			showBackups(items)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	list.Flags().String("site", "", color.Blue.Render("网站名称"))
+	list.Flags().String("db", "", color.Blue.Render("数据库名称，默认使用网站关联的数据库"))
+	list.Flags().Bool("with-db", false, color.Blue.Render("同时展示网站关联数据库的备份"))
+}
@@ -0,0 +1,18 @@
+package backup
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: color.Blue.Render("备份相关操作"),
+	Long:  color.Success.Render("\r\n备份相关操作"),
+}
+
+func init() {
+	BackupCmd.AddCommand(run)
+	BackupCmd.AddCommand(list)
+	BackupCmd.AddCommand(download)
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"jarvis/cmd/shared"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var run = &cobra.Command{
+	Use:   "run",
+	Short: "执行备份并应用保留策略",
+	Long:  color.Success.Render("\r\n触发宝塔的网站及数据库备份，等待完成后按保留策略清理旧备份"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		wait, _ := cmd.Flags().GetDuration("wait")
+		daily, _ := cmd.Flags().GetInt("keep-daily")
+		weekly, _ := cmd.Flags().GetInt("keep-weekly")
+
+		targets, err := resolveTargets(cmd)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range targets {
+			color.Blueln("开始备份" + t.label())
+
+			item, err := utils.RunBackup(host, key, t.Type, t.Id, wait)
+			if err != nil {
+				return fmt.Errorf("备份%s失败：%s", t.label(), err)
+			}
+			color.Infoln("备份完成：", item.Filename, shared.FormatBytes(item.Size))
+
+			items, err := listBackups(host, key, t)
+			if err != nil {
+				return err
+			}
+			showBackups(items)
+
+			if daily <= 0 && weekly <= 0 {
+				continue
+			}
+
+			_, remove := applyRetention(items, daily, weekly)
+			for _, old := range remove {
+				if err := utils.DeleteBackup(host, key, old); err != nil {
+					return fmt.Errorf("删除备份 %s 失败：%s", old.Filename, err)
+				}
+				color.Warnln("已按保留策略删除：", old.AddTime, old.Filename)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+// showBackups 输出备份列表
+func showBackups(items []utils.BackupItem) {
+	for _, item := range items {
+		color.Infoln(item.Id, item.AddTime, utils.StrPadLeft(shared.FormatBytes(item.Size), 10, " "), item.Filename)
+	}
+}
+
+func init() {
+	run.Flags().String("site", "", color.Blue.Render("网站名称"))
+	run.Flags().String("db", "", color.Blue.Render("数据库名称，默认使用网站关联的数据库"))
+	run.Flags().Bool("with-db", false, color.Blue.Render("同时备份网站关联的数据库"))
+	run.Flags().Duration("wait", 30*time.Minute, color.Blue.Render("等待备份完成的最长时间"))
+	run.Flags().Int("keep-daily", 0, color.Blue.Render("保留最近N天每天最新的一份备份，0表示不清理"))
+	run.Flags().Int("keep-weekly", 0, color.Blue.Render("保留最近N周每周最新的一份备份，0表示不清理"))
+}
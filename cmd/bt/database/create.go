@@ -23,22 +23,41 @@ var Create = &cobra.Command{
 		name, _ := cmd.Flags().GetString("name")
 		user, _ := cmd.Flags().GetString("user")
 		password, _ := cmd.Flags().GetString("password")
-		link := host + "/database?action=AddDatabase"
-
-		result := utils.Post(link, utils.PatchSign(key, url.Values{
-			"name":           {name},
-			"db_user":        {user},
-			"password":       {password},
-			"databaseAccess": {"127.0.0.1"},
-			"address":        {"127.0.0.1"},
-			"ps":             {name},
-			"dtype":          {"MySQL"},
-			"codeing":        {"utf8"},
-		}))
-		color.Infoln(result)
+
+		if err := Add(host, key, name, user, password); err != nil {
+			color.Errorln(err.Error())
+			return
+		}
+		color.Infoln("数据库创建成功：" + name)
 	},
 }
 
+// Add 创建MySQL数据库，仅允许本机访问
+func Add(host string, key string, name string, user string, password string) error {
+	link := host + "/database?action=AddDatabase"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"name":           {name},
+		"db_user":        {user},
+		"password":       {password},
+		"databaseAccess": {"127.0.0.1"},
+		"address":        {"127.0.0.1"},
+		"ps":             {name},
+		"dtype":          {"MySQL"},
+		"codeing":        {"utf8"},
+	}), nil)
+}
+
+// Import 将服务器上的SQL文件或备份导入数据库
+func Import(host string, key string, name string, file string) error {
+	link := host + "/database?action=InputSql"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"name": {name},
+		"file": {file},
+	}), nil)
+}
+
 func init() {
 	Create.Flags().String("name", "", color.Blue.Render("数据库名称"))
 	Create.Flags().String("user", "", color.Blue.Render("用户名"))
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"errors"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+)
+
+type DatabaseItem struct {
+	Id       int    `json:"id"`
+	Pid      int    `json:"pid"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Accept   string `json:"accept"`
+	Ps       string `json:"ps"`
+	AddTime  string `json:"addtime"`
+}
+
+// Get 获取数据库列表
+func Get(host string, key string) ([]DatabaseItem, error) {
+	link := host + "/data?action=getData&table=databases"
+
+	var result struct {
+		Data []DatabaseItem `json:"data"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"limit": {"1000"},
+		"p":     {"1"},
+	}), &result)
+
+	return result.Data, err
+}
+
+// Find 按名称查找数据库
+func Find(host string, key string, name string) (DatabaseItem, error) {
+	items, err := Get(host, key)
+	if err != nil {
+		return DatabaseItem{}, err
+	}
+
+	for _, item := range items {
+		if item.Name == name {
+			return item, nil
+		}
+	}
+
+	return DatabaseItem{}, errors.New("找不到数据库：" + name)
+}
+
+// FindBySite 查找网站关联的数据库
+func FindBySite(host string, key string, siteId int) ([]DatabaseItem, error) {
+	items, err := Get(host, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var related []DatabaseItem
+	for _, item := range items {
+		if item.Pid == siteId {
+			related = append(related, item)
+		}
+	}
+
+	return related, nil
+}
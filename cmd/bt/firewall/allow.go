@@ -0,0 +1,51 @@
+package firewall
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var allow = &cobra.Command{
+	Use:   "allow",
+	Short: "放行端口或IP",
+	Long:  color.Success.Render("\r\n放行端口、端口范围或IP，如：--port 8080、--port 8000-8100、--ip 1.2.3.4、--ip 10.0.0.0/8"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addRule(cmd, "accept")
+	},
+}
+
+// addRule 按 --port 或 --ip 添加放行或屏蔽规则
+func addRule(cmd *cobra.Command, types string) error {
+	host, _ := cmd.Flags().GetString("host")
+	key, _ := cmd.Flags().GetString("key")
+	port, _ := cmd.Flags().GetString("port")
+	ip, _ := cmd.Flags().GetString("ip")
+	protocol, _ := cmd.Flags().GetString("protocol")
+	ps, _ := cmd.Flags().GetString("ps")
+
+	value, isIP, err := target(port, ip)
+	if err != nil {
+		return err
+	}
+	if protocol, err = normalizeProtocol(protocol); err != nil {
+		return err
+	}
+	if isIP {
+		protocol = ""
+	}
+
+	if err := Add(host, key, isIP, value, protocol, types, ps); err != nil {
+		return err
+	}
+	color.Infoln("已" + describe(isIP, value, protocol, types))
+
+	return nil
+}
+
+func init() {
+	allow.Flags().String("port", "", color.Blue.Render("端口或端口范围"))
+	allow.Flags().String("ip", "", color.Blue.Render("IP或IP段"))
+	allow.Flags().String("protocol", "tcp", color.Blue.Render("端口的协议：tcp、udp、tcp/udp"))
+
+	allow.Flags().String("ps", "", color.Blue.Render("备注"))
+}
@@ -0,0 +1,23 @@
+package firewall
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var deny = &cobra.Command{
+	Use:   "deny",
+	Short: "屏蔽端口或IP",
+	Long:  color.Success.Render("\r\n屏蔽端口、端口范围或IP，如：--port 3306、--port 8000-8100、--ip 1.2.3.4、--ip 1.2.3.0/24"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addRule(cmd, "drop")
+	},
+}
+
+func init() {
+	deny.Flags().String("port", "", color.Blue.Render("端口或端口范围"))
+	deny.Flags().String("ip", "", color.Blue.Render("IP或IP段"))
+	deny.Flags().String("protocol", "tcp", color.Blue.Render("端口的协议：tcp、udp、tcp/udp"))
+
+	deny.Flags().String("ps", "", color.Blue.Render("备注"))
+}
@@ -0,0 +1,259 @@
+package firewall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RuleItem 宝塔防火墙规则，端口规则的 Ports 为端口或端口范围，IP规则的 Address 为IP或IP段，
+// Types 为 accept（放行）或 drop（屏蔽）
+type RuleItem struct {
+	Id       int    `json:"id"`
+	Protocol string `json:"protocol"`
+	Ports    string `json:"ports"`
+	Address  string `json:"address"`
+	Types    string `json:"types"`
+	Brief    string `json:"brief"`
+	AddTime  string `json:"addtime"`
+	// ip 来自IP规则列表
+	ip bool
+}
+
+// IsIP 判断是否为IP规则
+func (r RuleItem) IsIP() bool {
+	return r.ip
+}
+
+// Allowed 判断规则是否为放行
+func (r RuleItem) Allowed() bool {
+	return r.Types == "accept"
+}
+
+// Target 规则的端口或IP
+func (r RuleItem) Target() string {
+	if r.ip {
+		return r.Address
+	}
+
+	return r.Ports
+}
+
+// Proto 端口规则的协议：tcp、udp 或 tcp/udp，IP规则为空
+func (r RuleItem) Proto() string {
+	if r.ip {
+		return ""
+	}
+	if protocol, err := normalizeProtocol(r.Protocol); err == nil {
+		return protocol
+	}
+
+	return r.Protocol
+}
+
+// Value 规则的标准化取值，端口范围统一为 8000-8100 格式
+func (r RuleItem) Value() string {
+	if r.ip {
+		return r.Address
+	}
+	if port, err := normalizePort(r.Ports); err == nil {
+		return port
+	}
+
+	return r.Ports
+}
+
+// Get 获取端口规则及IP规则，需要宝塔 7.4 及以上版本的防火墙接口
+func Get(host string, key string) ([]RuleItem, error) {
+	ports, err := listRules(host+"/safe/firewall/get_rules_list", key)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := listRules(host+"/safe/firewall/get_ip_rules_list", key)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ips {
+		ips[i].ip = true
+	}
+
+	return append(ports, ips...), nil
+}
+
+// listRules 读取规则列表，不同版本的宝塔直接返回数组或放在 data 中
+func listRules(link string, key string) ([]RuleItem, error) {
+	result, err := utils.PostE(link, utils.PatchSign(key, url.Values{
+		"p":     {"1"},
+		"limit": {"1000"},
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []RuleItem
+	if json.Unmarshal([]byte(result), &rules) == nil {
+		return rules, nil
+	}
+
+	var wrapped struct {
+		Status *bool      `json:"status"`
+		Msg    string     `json:"msg"`
+		Data   []RuleItem `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(result), &wrapped); err != nil {
+		return nil, fmt.Errorf("无法解析宝塔响应：%s", result)
+	}
+	if wrapped.Status != nil && !*wrapped.Status {
+		return nil, errors.New(wrapped.Msg)
+	}
+
+	return wrapped.Data, nil
+}
+
+// Add 添加规则，types 为 accept 或 drop，ip为true时value为IP，否则为端口或端口范围，protocol 只用于端口规则
+func Add(host string, key string, ip bool, value string, protocol string, types string, ps string) error {
+	if ip {
+		return utils.PostJSON(host+"/safe/firewall/create_ip_rules", utils.PatchSign(key, url.Values{
+			"address": {value},
+			"types":   {types},
+			"brief":   {ps},
+		}), nil)
+	}
+
+	return utils.PostJSON(host+"/safe/firewall/create_rules", utils.PatchSign(key, url.Values{
+		"protocol": {protocol},
+		"ports":    {value},
+		"choose":   {"all"},
+		"address":  {""},
+		"domain":   {""},
+		"types":    {types},
+		"brief":    {ps},
+		"source":   {""},
+	}), nil)
+}
+
+// Remove 删除规则
+func Remove(host string, key string, rule RuleItem) error {
+	if rule.ip {
+		return utils.PostJSON(host+"/safe/firewall/remove_ip_rules", utils.PatchSign(key, url.Values{
+			"id":      {fmt.Sprint(rule.Id)},
+			"address": {rule.Address},
+			"types":   {rule.Types},
+		}), nil)
+	}
+
+	return utils.PostJSON(host+"/safe/firewall/remove_rules", utils.PatchSign(key, url.Values{
+		"id":       {fmt.Sprint(rule.Id)},
+		"protocol": {rule.Protocol},
+		"ports":    {rule.Ports},
+		"address":  {rule.Address},
+		"types":    {rule.Types},
+	}), nil)
+}
+
+// target 由 --port 及 --ip 得到规则的标准化取值，两者只能指定一个
+func target(port string, ip string) (string, bool, error) {
+	switch {
+	case port != "" && ip != "":
+		return "", false, errors.New("--port 与 --ip 只能指定一个")
+	case port != "":
+		value, err := normalizePort(port)
+		return value, false, err
+	case ip != "":
+		value, err := normalizeIP(ip)
+		return value, true, err
+	}
+
+	return "", false, errors.New("请通过 --port 或 --ip 指定端口或IP")
+}
+
+// normalizePort 校验端口或端口范围，统一为宝塔使用的 8000-8100 格式
+func normalizePort(port string) (string, error) {
+	parts := strings.FieldsFunc(port, func(r rune) bool { return r == '-' || r == ':' })
+	if len(parts) == 0 || len(parts) > 2 {
+		return "", errors.New("无效的端口：" + port)
+	}
+
+	numbers := []int{}
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > 65535 {
+			return "", errors.New("无效的端口：" + port)
+		}
+		numbers = append(numbers, n)
+	}
+
+	if len(numbers) == 2 {
+		if numbers[0] >= numbers[1] {
+			return "", errors.New("端口范围的起始端口应小于结束端口：" + port)
+		}
+		return fmt.Sprintf("%d-%d", numbers[0], numbers[1]), nil
+	}
+
+	return fmt.Sprint(numbers[0]), nil
+}
+
+// normalizeProtocol 校验端口规则的协议，默认为 tcp
+func normalizeProtocol(protocol string) (string, error) {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	switch protocol {
+	case "":
+		return "tcp", nil
+	case "tcp", "udp", "tcp/udp":
+		return protocol, nil
+	}
+
+	return "", errors.New("无效的协议：" + protocol + "，可选 tcp、udp、tcp/udp")
+}
+
+// portRange 端口或端口范围的起止端口
+func portRange(port string) (int, int) {
+	parts := strings.SplitN(port, "-", 2)
+	low, _ := strconv.Atoi(parts[0])
+	high := low
+	if len(parts) == 2 {
+		high, _ = strconv.Atoi(parts[1])
+	}
+
+	return low, high
+}
+
+// normalizeIP 校验IP或CIDR
+func normalizeIP(ip string) (string, error) {
+	ip = strings.TrimSpace(ip)
+	if !isIP(ip) {
+		return "", errors.New("无效的IP：" + ip)
+	}
+
+	return ip, nil
+}
+
+func isIP(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+
+	return err == nil
+}
+
+// describe 规则的说明，如 放行端口 22、放行端口 53/udp、屏蔽IP 1.2.3.4，tcp 不显示协议
+func describe(ip bool, value string, protocol string, types string) string {
+	action := "屏蔽"
+	if types == "accept" {
+		action = "放行"
+	}
+	if ip {
+		return action + "IP " + value
+	}
+	if protocol != "" && protocol != "tcp" {
+		value += "/" + protocol
+	}
+
+	return action + "端口 " + value
+}
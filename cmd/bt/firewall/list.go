@@ -0,0 +1,34 @@
+package firewall
+
+import (
+	"jarvis/cmd/bt/utils"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "展示防火墙规则",
+	Long:  color.Success.Render("\r\n展示放行及屏蔽的端口和IP"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+
+		rules, err := Get(host, key)
+		if err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			line := utils.StrPadRight(describe(rule.IsIP(), rule.Target(), rule.Proto(), rule.Types), 28, " ")
+			if rule.Allowed() {
+				color.Infoln(rule.Id, line, rule.Brief)
+			} else {
+				color.Warnln(rule.Id, line, rule.Brief)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,60 @@
+package firewall
+
+import (
+	"errors"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var remove = &cobra.Command{
+	Use:   "remove",
+	Short: "删除防火墙规则",
+	Long:  color.Success.Render("\r\n删除端口或IP的放行及屏蔽规则"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		port, _ := cmd.Flags().GetString("port")
+		ip, _ := cmd.Flags().GetString("ip")
+		protocol, _ := cmd.Flags().GetString("protocol")
+
+		value, isIP, err := target(port, ip)
+		if err != nil {
+			return err
+		}
+		if protocol != "" {
+			if protocol, err = normalizeProtocol(protocol); err != nil {
+				return err
+			}
+		}
+
+		rules, err := Get(host, key)
+		if err != nil {
+			return err
+		}
+
+		removed := 0
+		for _, rule := range rules {
+			if rule.IsIP() != isIP || rule.Value() != value || protocol != "" && rule.Proto() != protocol {
+				continue
+			}
+			if err := Remove(host, key, rule); err != nil {
+				return err
+			}
+			color.Infoln("已删除规则：" + describe(rule.IsIP(), rule.Target(), rule.Proto(), rule.Types))
+			removed++
+		}
+		if removed == 0 {
+			return errors.New("找不到相关规则：" + value)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	remove.Flags().String("port", "", color.Blue.Render("端口或端口范围"))
+	remove.Flags().String("ip", "", color.Blue.Render("IP或IP段"))
+	remove.Flags().String("protocol", "", color.Blue.Render("只删除该协议的端口规则，默认删除所有协议"))
+
+}
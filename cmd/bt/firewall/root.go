@@ -0,0 +1,35 @@
+package firewall
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var FirewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: color.Blue.Render("防火墙相关操作"),
+	Long:  color.Success.Render("\r\n防火墙相关操作，使用 --sync -f rules.yaml 将规则同步为文件中声明的状态"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		sync, _ := cmd.Flags().GetBool("sync")
+		file, _ := cmd.Flags().GetString("file")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if !sync {
+			return cmd.Help()
+		}
+
+		return syncRules(host, key, file, yes)
+	},
+}
+
+func init() {
+	FirewallCmd.AddCommand(list)
+	FirewallCmd.AddCommand(allow)
+	FirewallCmd.AddCommand(deny)
+	FirewallCmd.AddCommand(remove)
+	FirewallCmd.Flags().Bool("sync", false, color.Blue.Render("将防火墙规则同步为规则文件中声明的状态"))
+	FirewallCmd.Flags().StringP("file", "f", "rules.yaml", color.Blue.Render("规则文件"))
+	FirewallCmd.Flags().BoolP("yes", "y", false, color.Blue.Render("关闭端口前不再确认"))
+}
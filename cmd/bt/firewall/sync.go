@@ -0,0 +1,199 @@
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/shared"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gookit/color"
+	"gopkg.in/yaml.v3"
+)
+
+// ruleSet 声明式的防火墙规则文件，每一项为端口或IP，端口的协议默认为 tcp
+//
+//	allow:
+//	  - port: 22
+//	    ps: ssh
+//	  - port: 8000-8100
+//	  - port: 53
+//	    protocol: udp
+//	  - ip: 10.0.0.0/8
+//	deny:
+//	  - ip: 1.2.3.4
+//	  - port: 3306
+type ruleSet struct {
+	Allow []ruleEntry `yaml:"allow"`
+	Deny  []ruleEntry `yaml:"deny"`
+}
+
+type ruleEntry struct {
+	Port     string `yaml:"port"`
+	IP       string `yaml:"ip"`
+	Protocol string `yaml:"protocol"`
+	Ps       string `yaml:"ps"`
+}
+
+// ruleKey 规则的类型、标准化取值、协议及放行或屏蔽，IP规则的协议为空
+type ruleKey struct {
+	IP       bool
+	Value    string
+	Protocol string
+	Types    string
+}
+
+func (k ruleKey) String() string {
+	return describe(k.IP, k.Value, k.Protocol, k.Types)
+}
+
+func keyOf(rule RuleItem) ruleKey {
+	return ruleKey{IP: rule.IsIP(), Value: rule.Value(), Protocol: rule.Proto(), Types: rule.Types}
+}
+
+// loadRules 读取规则文件，返回规则到备注的映射
+func loadRules(file string) (map[ruleKey]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var set ruleSet
+	if err := yaml.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("解析规则文件失败：%s", err)
+	}
+
+	desired := map[ruleKey]string{}
+	for types, entries := range map[string][]ruleEntry{"accept": set.Allow, "drop": set.Deny} {
+		for _, entry := range entries {
+			value, isIP, err := target(entry.Port, entry.IP)
+			if err != nil {
+				return nil, err
+			}
+			protocol := ""
+			if !isIP {
+				if protocol, err = normalizeProtocol(entry.Protocol); err != nil {
+					return nil, err
+				}
+			}
+			desired[ruleKey{IP: isIP, Value: value, Protocol: protocol, Types: types}] = entry.Ps
+		}
+	}
+
+	return desired, nil
+}
+
+// protectedPorts 宝塔面板的端口及 SSH 端口，规则文件没有列出时不删除放行这些端口的规则
+func protectedPorts(host string) []int {
+	ports := []int{22}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return ports
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 {
+		port = 80
+		if u.Scheme == "https" {
+			port = 443
+		}
+	}
+
+	return append(ports, port)
+}
+
+// locksOut 删除规则是否会关闭规则文件中没有列出的面板或 SSH 端口
+func locksOut(rule RuleItem, desired map[ruleKey]string, protected []int) (int, bool) {
+	if rule.IsIP() || !rule.Allowed() {
+		return 0, false
+	}
+
+	low, high := portRange(rule.Value())
+	for _, port := range protected {
+		if port < low || port > high {
+			continue
+		}
+		listed := false
+		for k := range desired {
+			if from, to := portRange(k.Value); !k.IP && from <= port && port <= to {
+				listed = true
+			}
+		}
+		if !listed {
+			return port, true
+		}
+	}
+
+	return 0, false
+}
+
+// syncRules 将宝塔的防火墙规则调整为规则文件中声明的状态
+func syncRules(host string, key string, file string, yes bool) error {
+	desired, err := loadRules(file)
+	if err != nil {
+		return err
+	}
+
+	rules, err := Get(host, key)
+	if err != nil {
+		return err
+	}
+
+	protected := protectedPorts(host)
+	current := map[ruleKey]bool{}
+	var removes []RuleItem
+	for _, rule := range rules {
+		current[keyOf(rule)] = true
+		if _, ok := desired[keyOf(rule)]; ok {
+			continue
+		}
+		if port, ok := locksOut(rule, desired, protected); ok {
+			color.Warnln(fmt.Sprintf("跳过删除 %s：会关闭面板或 SSH 使用的端口 %d，如需删除请在规则文件中明确列出该端口", keyOf(rule), port))
+			continue
+		}
+		removes = append(removes, rule)
+	}
+
+	var adds []ruleKey
+	for k := range desired {
+		if !current[k] {
+			adds = append(adds, k)
+		}
+	}
+	sort.Slice(adds, func(i, j int) bool { return adds[i].String() < adds[j].String() })
+
+	if len(adds) == 0 && len(removes) == 0 {
+		color.Infoln("防火墙规则已与规则文件一致")
+		return nil
+	}
+
+	for _, k := range adds {
+		color.Green.Println("+ " + k.String() + " " + desired[k])
+	}
+	for _, rule := range removes {
+		color.Red.Println("- " + keyOf(rule).String() + " " + rule.Brief)
+	}
+	fmt.Println()
+
+	if len(removes) > 0 && !yes && !shared.Confirm("以上标记为 - 的规则将被删除，放行的端口将被关闭，是否继续？") {
+		return errors.New("已取消")
+	}
+
+	// 先添加再删除，中途失败时不会先关闭仍需放行的端口
+	for _, k := range adds {
+		if err := Add(host, key, k.IP, k.Value, k.Protocol, k.Types, desired[k]); err != nil {
+			return fmt.Errorf("添加规则 %s 失败：%s", k, err)
+		}
+	}
+	for _, rule := range removes {
+		if err := Remove(host, key, rule); err != nil {
+			return fmt.Errorf("删除规则 %s 失败：%s", keyOf(rule), err)
+		}
+	}
+
+	color.Infoln(fmt.Sprintf("同步完成：新增 %d 条，删除 %d 条", len(adds), len(removes)))
+
+	return nil
+}
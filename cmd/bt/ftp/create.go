@@ -0,0 +1,73 @@
+package ftp
+
+import (
+	"errors"
+	"jarvis/cmd/bt/site"
+	"jarvis/cmd/shared"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var create = &cobra.Command{
+	Use:   "create",
+	Short: "创建FTP账号",
+	Long:  color.Success.Render("\r\n为网站创建FTP账号，可通过 --expires 设置有效期，到期后由 bt ftp reap 停用"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		name, _ := cmd.Flags().GetString("name")
+		password, _ := cmd.Flags().GetString("password")
+		siteName, _ := cmd.Flags().GetString("site")
+		path, _ := cmd.Flags().GetString("path")
+		ps, _ := cmd.Flags().GetString("ps")
+		expiresFlag, _ := cmd.Flags().GetString("expires")
+
+		expires, err := parseExpires(expiresFlag)
+		if err != nil {
+			return err
+		}
+
+		if path == "" {
+			if siteName == "" {
+				return errors.New("请通过 --site 或 --path 指定FTP目录")
+			}
+			item, err := site.Find(host, key, siteName)
+			if err != nil {
+				return err
+			}
+			path = item.Path
+		}
+
+		if password == "" {
+			password = shared.RandomString(16)
+		}
+
+		if err := Add(host, key, name, password, path, ps); err != nil {
+			return err
+		}
+		if err := setExpiry(host, name, expires); err != nil {
+			return err
+		}
+
+		color.Infoln("FTP账号创建成功")
+		color.Infoln("用户名：", name)
+		color.Infoln("密码：", password)
+		color.Infoln("目录：", path)
+		if !expires.IsZero() {
+			color.Infoln("到期时间：", expires.Format("2006-01-02 15:04"))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	create.Flags().String("name", "", color.Blue.Render("用户名"))
+	create.Flags().String("password", "", color.Blue.Render("密码，默认随机生成"))
+	create.Flags().String("site", "", color.Blue.Render("网站名称，FTP目录为网站根目录"))
+	create.Flags().String("path", "", color.Blue.Render("FTP目录"))
+	create.Flags().String("ps", "", color.Blue.Render("备注"))
+	create.Flags().String("expires", "", color.Blue.Render("有效期，如：72h、7d、2006-01-02"))
+	create.MarkFlagRequired("name")
+}
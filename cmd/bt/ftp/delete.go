@@ -0,0 +1,39 @@
+package ftp
+
+import (
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var delete = &cobra.Command{
+	Use:   "delete",
+	Short: "删除FTP账号",
+	Long:  color.Success.Render("\r\n删除FTP账号"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		name, _ := cmd.Flags().GetString("name")
+
+		item, err := Find(host, key, name)
+		if err != nil {
+			return err
+		}
+
+		if err := Delete(host, key, item); err != nil {
+			return err
+		}
+		if err := setExpiry(host, name, time.Time{}); err != nil {
+			return err
+		}
+		color.Infoln("已删除FTP账号：" + name)
+
+		return nil
+	},
+}
+
+func init() {
+	delete.Flags().String("name", "", color.Blue.Render("用户名"))
+	delete.MarkFlagRequired("name")
+}
@@ -0,0 +1,84 @@
+package ftp
+
+import (
+	"errors"
+	"jarvis/cmd/bt/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本地记录的FTP账号到期时间，宝塔本身不支持账号有效期
+const expiryFile = "ftp.json"
+
+// expiry 一条到期记录
+type expiry struct {
+	Host    string    `json:"host"`
+	Name    string    `json:"name"`
+	Expires time.Time `json:"expires"`
+}
+
+func loadExpiries() ([]expiry, error) {
+	expiries := []expiry{}
+	err := utils.LoadState(expiryFile, &expiries)
+
+	return expiries, err
+}
+
+// setExpiry 记录账号的到期时间，零值表示删除记录
+func setExpiry(host string, name string, expires time.Time) error {
+	expiries, err := loadExpiries()
+	if err != nil {
+		return err
+	}
+
+	kept := []expiry{}
+	for _, e := range expiries {
+		if e.Host != host || e.Name != name {
+			kept = append(kept, e)
+		}
+	}
+	if !expires.IsZero() {
+		kept = append(kept, expiry{Host: host, Name: name, Expires: expires})
+	}
+
+	return utils.SaveState(expiryFile, kept)
+}
+
+// findExpiry 查找账号的到期时间，没有记录时返回零值
+func findExpiry(expiries []expiry, host string, name string) time.Time {
+	for _, e := range expiries {
+		if e.Host == host && e.Name == name {
+			return e.Expires
+		}
+	}
+
+	return time.Time{}
+}
+
+// parseExpires 解析有效期，支持时长（72h、7d）及日期（2006-01-02）
+func parseExpires(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days > 0 {
+			return time.Now().AddDate(0, 0, days), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return time.Now().Add(duration), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("无法解析有效期，支持 72h、7d 或 2006-01-02：" + value)
+}
@@ -0,0 +1,105 @@
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+)
+
+type FtpItem struct {
+	Id       int    `json:"id"`
+	Pid      int    `json:"pid"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Status   string `json:"status"`
+	Path     string `json:"path"`
+	Ps       string `json:"ps"`
+	AddTime  string `json:"addtime"`
+}
+
+// Enabled 账号是否启用
+func (f FtpItem) Enabled() bool {
+	return f.Status == "1"
+}
+
+// Get 获取FTP账号列表
+func Get(host string, key string) ([]FtpItem, error) {
+	link := host + "/data?action=getData&table=ftps"
+
+	var result struct {
+		Data []FtpItem `json:"data"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"limit": {"1000"},
+		"p":     {"1"},
+	}), &result)
+
+	return result.Data, err
+}
+
+// Find 按用户名查找FTP账号
+func Find(host string, key string, name string) (FtpItem, error) {
+	items, err := Get(host, key)
+	if err != nil {
+		return FtpItem{}, err
+	}
+
+	for _, item := range items {
+		if item.Name == name {
+			return item, nil
+		}
+	}
+
+	return FtpItem{}, errors.New("找不到FTP账号：" + name)
+}
+
+// Add 创建FTP账号
+func Add(host string, key string, name string, password string, path string, ps string) error {
+	link := host + "/ftp?action=AddUser"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"ftp_username": {name},
+		"ftp_password": {password},
+		"path":         {path},
+		"ps":           {ps},
+	}), nil)
+}
+
+// Delete 删除FTP账号
+func Delete(host string, key string, item FtpItem) error {
+	link := host + "/ftp?action=DeleteUser"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"id":       {fmt.Sprint(item.Id)},
+		"username": {item.Name},
+	}), nil)
+}
+
+// SetPassword 修改FTP账号密码
+func SetPassword(host string, key string, item FtpItem, password string) error {
+	link := host + "/ftp?action=SetUserPassword"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"id":           {fmt.Sprint(item.Id)},
+		"ftp_username": {item.Name},
+		"new_password": {password},
+	}), nil)
+}
+
+// SetStatus 启用或停用FTP账号
+func SetStatus(host string, key string, item FtpItem, enabled bool) error {
+	link := host + "/ftp?action=SetStatus"
+
+	status := "0"
+	if enabled {
+		status = "1"
+	}
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"id":       {fmt.Sprint(item.Id)},
+		"username": {item.Name},
+		"status":   {status},
+	}), nil)
+}
@@ -0,0 +1,52 @@
+package ftp
+
+import (
+	"jarvis/cmd/bt/utils"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "展示FTP账号列表",
+	Long:  color.Success.Render("\r\n展示FTP账号列表及本地记录的到期时间"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+
+		items, err := Get(host, key)
+		if err != nil {
+			return err
+		}
+		expiries, err := loadExpiries()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			status := "已停用"
+			if item.Enabled() {
+				status = "已启用"
+			}
+
+			expires := "长期"
+			if t := findExpiry(expiries, host, item.Name); !t.IsZero() {
+				expires = t.Format("2006-01-02 15:04")
+				if t.Before(time.Now()) {
+					expires += "（已到期）"
+				}
+			}
+
+			line := []interface{}{item.Id, utils.StrPadRight(item.Name, 20, " "), status, utils.StrPadRight(expires, 22, " "), item.Path}
+			if item.Enabled() {
+				color.Infoln(line...)
+			} else {
+				color.Warnln(line...)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,42 @@
+package ftp
+
+import (
+	"jarvis/cmd/shared"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var passwd = &cobra.Command{
+	Use:   "passwd",
+	Short: "修改FTP账号密码",
+	Long:  color.Success.Render("\r\n修改FTP账号密码"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		name, _ := cmd.Flags().GetString("name")
+		password, _ := cmd.Flags().GetString("password")
+
+		item, err := Find(host, key, name)
+		if err != nil {
+			return err
+		}
+
+		if password == "" {
+			password = shared.RandomString(16)
+		}
+
+		if err := SetPassword(host, key, item, password); err != nil {
+			return err
+		}
+		color.Infoln("密码已修改：", password)
+
+		return nil
+	},
+}
+
+func init() {
+	passwd.Flags().String("name", "", color.Blue.Render("用户名"))
+	passwd.Flags().String("password", "", color.Blue.Render("新密码，默认随机生成"))
+	passwd.MarkFlagRequired("name")
+}
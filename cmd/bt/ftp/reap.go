@@ -0,0 +1,58 @@
+package ftp
+
+import (
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var reap = &cobra.Command{
+	Use:   "reap",
+	Short: "停用已到期的FTP账号",
+	Long:  color.Success.Render("\r\n停用已到期的FTP账号，适合放在crontab中定期执行"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+
+		expiries, err := loadExpiries()
+		if err != nil {
+			return err
+		}
+		items, err := Get(host, key)
+		if err != nil {
+			return err
+		}
+
+		accounts := map[string]FtpItem{}
+		for _, item := range items {
+			accounts[item.Name] = item
+		}
+
+		reaped := 0
+		for _, e := range expiries {
+			if e.Host != host || e.Expires.After(time.Now()) {
+				continue
+			}
+
+			item, ok := accounts[e.Name]
+			if !ok {
+				color.Warnln("FTP账号已不存在，删除到期记录：" + e.Name)
+			} else if item.Enabled() {
+				if err := SetStatus(host, key, item, false); err != nil {
+					return err
+				}
+				color.Infoln("已停用到期的FTP账号：", e.Name, e.Expires.Format("2006-01-02 15:04"))
+				reaped++
+			}
+
+			if err := setExpiry(host, e.Name, time.Time{}); err != nil {
+				return err
+			}
+		}
+
+		color.Infoln("共停用", reaped, "个账号")
+
+		return nil
+	},
+}
@@ -0,0 +1,22 @@
+package ftp
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var FtpCmd = &cobra.Command{
+	Use:   "ftp",
+	Short: color.Blue.Render("FTP相关操作"),
+	Long:  color.Success.Render("\r\nFTP相关操作"),
+}
+
+func init() {
+	FtpCmd.AddCommand(list)
+	FtpCmd.AddCommand(create)
+	FtpCmd.AddCommand(delete)
+	FtpCmd.AddCommand(passwd)
+	FtpCmd.AddCommand(enable)
+	FtpCmd.AddCommand(disable)
+	FtpCmd.AddCommand(reap)
+}
@@ -0,0 +1,70 @@
+package ftp
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var enable = &cobra.Command{
+	Use:   "enable",
+	Short: "启用FTP账号",
+	Long:  color.Success.Render("\r\n启用FTP账号，可通过 --expires 重新设置有效期"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		name, _ := cmd.Flags().GetString("name")
+		expiresFlag, _ := cmd.Flags().GetString("expires")
+
+		expires, err := parseExpires(expiresFlag)
+		if err != nil {
+			return err
+		}
+
+		item, err := Find(host, key, name)
+		if err != nil {
+			return err
+		}
+
+		if err := SetStatus(host, key, item, true); err != nil {
+			return err
+		}
+		// 未指定有效期时清除到期记录，避免账号被立即停用
+		if err := setExpiry(host, name, expires); err != nil {
+			return err
+		}
+		color.Infoln("已启用FTP账号：" + name)
+
+		return nil
+	},
+}
+
+var disable = &cobra.Command{
+	Use:   "disable",
+	Short: "停用FTP账号",
+	Long:  color.Success.Render("\r\n停用FTP账号"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		name, _ := cmd.Flags().GetString("name")
+
+		item, err := Find(host, key, name)
+		if err != nil {
+			return err
+		}
+
+		if err := SetStatus(host, key, item, false); err != nil {
+			return err
+		}
+		color.Infoln("已停用FTP账号：" + name)
+
+		return nil
+	},
+}
+
+func init() {
+	enable.Flags().String("name", "", color.Blue.Render("用户名"))
+	enable.Flags().String("expires", "", color.Blue.Render("有效期，如：72h、7d、2006-01-02"))
+	enable.MarkFlagRequired("name")
+	disable.Flags().String("name", "", color.Blue.Render("用户名"))
+	disable.MarkFlagRequired("name")
+}
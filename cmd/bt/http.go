@@ -1,32 +1,129 @@
 package bt
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"jarvis/cmd/bt/utils"
 	"net/url"
+	"os"
+	"strings"
 
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
 )
 
+// Snippet 保存下来的请求，可通过 bt http run <name> 重放
+type Snippet struct {
+	Query  string   `json:"query"`
+	Data   string   `json:"data,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+	Jq     string   `json:"jq,omitempty"`
+}
+
+const snippetFile = "http.json"
+
 var Http = &cobra.Command{
 	Use:   "http",
 	Short: color.Blue.Render("发送HTTP请求"),
-	Run: func(cmd *cobra.Command, args []string) {
+	Long:  color.Success.Render("\r\n发送HTTP请求，格式化输出JSON，支持提取字段、上传文件以及保存为片段"),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		host, _ := cmd.Flags().GetString("host")
 		key, _ := cmd.Flags().GetString("key")
+		save, _ := cmd.Flags().GetString("save")
+
+		snippet := Snippet{}
+		snippet.Query, _ = cmd.Flags().GetString("query")
+		snippet.Data, _ = cmd.Flags().GetString("data")
+		snippet.Fields, _ = cmd.Flags().GetStringArray("field")
+		snippet.Jq, _ = cmd.Flags().GetString("jq")
 
-		query, _ := cmd.Flags().GetString("query")
-		data, _ := cmd.Flags().GetString("data")
-		link := host + query
+		if save != "" {
+			snippets := map[string]Snippet{}
+			if err := utils.LoadState(snippetFile, &snippets); err != nil {
+				return err
+			}
+			snippets[save] = snippet
+			if err := utils.SaveState(snippetFile, snippets); err != nil {
+				return err
+			}
+			color.Blueln("已保存为片段：" + save + "\r\n")
+		}
 
-		parsedData, _ := url.ParseQuery(data)
-		result := utils.Post(link, utils.PatchSign(key, parsedData))
-		color.Infoln(result)
+		return sendSnippet(host, key, snippet)
 	},
 }
 
+// sendSnippet 发送请求并按jq表达式输出结果
+func sendSnippet(host string, key string, snippet Snippet) error {
+	data, err := readData(snippet.Data)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{}
+	for _, field := range snippet.Fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.New("字段格式应为 key=value：" + field)
+		}
+		if strings.HasPrefix(parts[1], "@") {
+			files[parts[0]] = strings.TrimPrefix(parts[1], "@")
+		} else {
+			data.Add(parts[0], parts[1])
+		}
+	}
+
+	link := host + snippet.Query
+	data = utils.PatchSign(key, data)
+
+	var result string
+	if len(files) > 0 {
+		result, err = utils.PostMultipart(link, data, files)
+	} else {
+		result, err = utils.PostE(link, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if snippet.Jq == "" {
+		fmt.Println(utils.PrettyJSON(result))
+		return nil
+	}
+
+	values, err := utils.Query(result, snippet.Jq)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		encoded, _ := json.Marshal(value)
+		fmt.Println(utils.PrettyJSON(string(encoded)))
+	}
+
+	return nil
+}
+
+// readData 解析请求数据，以@开头时从文件读取
+func readData(data string) (url.Values, error) {
+	if strings.HasPrefix(data, "@") {
+		content, err := os.ReadFile(strings.TrimPrefix(data, "@"))
+		if err != nil {
+			return nil, err
+		}
+		data = strings.TrimSpace(string(content))
+	}
+
+	return url.ParseQuery(data)
+}
+
 func init() {
+	Http.AddCommand(httpRun)
+	Http.AddCommand(httpList)
 	Http.Flags().String("query", "", color.Blue.Render("URL查询参数，如：/plugin?action=a&name=supervisor&s=AddProcess"))
-	Http.Flags().String("data", "", color.Blue.Render("发送的数据，如：pjname=abcd&user=www&path=/&command=tail -f /dev/null&numprocs=1"))
+	Http.Flags().String("data", "", color.Blue.Render("发送的数据，如：pjname=abcd&user=www&path=/&command=tail -f /dev/null&numprocs=1，以@开头时从文件读取"))
+	Http.Flags().StringArrayP("field", "F", []string{}, color.Blue.Render("表单字段，可重复，如：-F name=abc，-F file=@/path/to/file 以multipart方式上传文件"))
+	Http.Flags().String("jq", "", color.Blue.Render("提取字段的路径表达式，如：.data[0].name、.data[].id"))
+	Http.Flags().String("save", "", color.Blue.Render("将本次请求保存为片段"))
 	Http.MarkFlagRequired("query")
 }
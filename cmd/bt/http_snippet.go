@@ -0,0 +1,67 @@
+package bt
+
+import (
+	"errors"
+	"jarvis/cmd/bt/utils"
+	"sort"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var httpRun = &cobra.Command{
+	Use:   "run <name>",
+	Short: "重放保存的请求片段",
+	Long:  color.Success.Render("\r\n重放通过 bt http --save 保存的请求片段"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		jq, _ := cmd.Flags().GetString("jq")
+
+		snippets := map[string]Snippet{}
+		if err := utils.LoadState(snippetFile, &snippets); err != nil {
+			return err
+		}
+
+		snippet, ok := snippets[args[0]]
+		if !ok {
+			return errors.New("找不到片段：" + args[0])
+		}
+		if jq != "" {
+			snippet.Jq = jq
+		}
+
+		return sendSnippet(host, key, snippet)
+	},
+}
+
+var httpList = &cobra.Command{
+	Use:   "list",
+	Short: "展示保存的请求片段",
+	Long:  color.Success.Render("\r\n展示保存的请求片段"),
+	// 不需要访问宝塔，跳过地址与密钥检查
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		snippets := map[string]Snippet{}
+		if err := utils.LoadState(snippetFile, &snippets); err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(snippets))
+		for name := range snippets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			color.Infoln(utils.StrPadRight(name, 20, " "), snippets[name].Query)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	httpRun.Flags().String("jq", "", color.Blue.Render("覆盖片段中保存的路径表达式"))
+}
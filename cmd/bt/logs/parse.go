@@ -0,0 +1,129 @@
+package logs
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry 一条访问日志
+type Entry struct {
+	IP          string
+	Time        time.Time
+	Method      string
+	URL         string
+	Status      int
+	Bytes       int64
+	Referer     string
+	UserAgent   string
+	RequestTime float64
+	HasDuration bool
+}
+
+// Path 去掉查询参数后的URL
+func (e Entry) Path() string {
+	return strings.SplitN(e.URL, "?", 2)[0]
+}
+
+// combinedPattern nginx combined格式，末尾可选的 $request_time
+var combinedPattern = regexp.MustCompile(`^(\S+) \S+ \S+ \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+) "([^"]*)" "([^"]*)"(?:\s+"?([\d.]+)"?)?`)
+
+// parseLine 解析一行访问日志
+func parseLine(line string) (Entry, bool) {
+	match := combinedPattern.FindStringSubmatch(line)
+	if match == nil {
+		return Entry{}, false
+	}
+
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", match[2])
+	if err != nil {
+		return Entry{}, false
+	}
+
+	entry := Entry{
+		IP:        match[1],
+		Time:      t,
+		Referer:   match[6],
+		UserAgent: match[7],
+	}
+
+	request := strings.Fields(match[3])
+	if len(request) >= 2 {
+		entry.Method = request[0]
+		entry.URL = request[1]
+	} else {
+		entry.URL = match[3]
+	}
+
+	entry.Status, _ = strconv.Atoi(match[4])
+	entry.Bytes, _ = strconv.ParseInt(match[5], 10, 64)
+
+	if match[8] != "" {
+		entry.RequestTime, _ = strconv.ParseFloat(match[8], 64)
+		entry.HasDuration = true
+	}
+
+	return entry, true
+}
+
+// parseLog 解析访问日志，只保留since之后的记录，返回记录及无法解析的行数
+func parseLog(reader io.Reader, since time.Time) ([]Entry, int) {
+	entries := []Entry{}
+	skipped := 0
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entry, ok := parseLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, skipped
+}
+
+// errorPattern nginx错误日志，如：2023/01/01 12:00:00 [error] 1234#0: *5 message
+var errorPattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (.*)$`)
+
+// ErrorEntry 一条错误日志
+type ErrorEntry struct {
+	Time    time.Time
+	Level   string
+	Message string
+}
+
+// parseErrors 解析错误日志，只保留since之后的记录
+func parseErrors(reader io.Reader, since time.Time) []ErrorEntry {
+	entries := []ErrorEntry{}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := errorPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006/01/02 15:04:05", match[1], time.Local)
+		if err != nil || t.Before(since) {
+			continue
+		}
+		entries = append(entries, ErrorEntry{Time: t, Level: match[2], Message: match[3]})
+	}
+
+	return entries
+}
@@ -0,0 +1,215 @@
+package logs
+
+import (
+	"fmt"
+	"jarvis/cmd/shared"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+// counter 计数结果
+type counter struct {
+	Key   string
+	Count int
+}
+
+// topN 按次数从多到少排序并取前n个
+func topN(counts map[string]int, n int) []counter {
+	result := make([]counter, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, counter{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Count > result[j].Count
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+
+	return result
+}
+
+// showReport 输出访问日志统计
+func showReport(entries []Entry, skipped int, top int) {
+	color.Blue.Println("📊 访问概况")
+	if len(entries) == 0 {
+		color.Warnln("没有符合条件的访问记录")
+		return
+	}
+
+	ips := map[string]int{}
+	urls := map[string]int{}
+	statuses := map[string]int{}
+	var bytes int64
+	for _, entry := range entries {
+		ips[entry.IP]++
+		urls[entry.Method+" "+entry.Path()]++
+		statuses[fmt.Sprint(entry.Status)]++
+		bytes += entry.Bytes
+	}
+
+	first, last := timeRange(entries)
+	fmt.Printf("请求数：%d  独立IP：%d  流量：%s\n", len(entries), len(ips), shared.FormatBytes(bytes))
+	fmt.Printf("时间范围：%s ~ %s\n", first.Local().Format("2006-01-02 15:04:05"), last.Local().Format("2006-01-02 15:04:05"))
+	if skipped > 0 {
+		color.Warnln(fmt.Sprintf("有 %d 行无法按combined格式解析，已忽略", skipped))
+	}
+	fmt.Println()
+
+	color.Blue.Println("🌐 访问最多的IP")
+	showCounters(topN(ips, top), len(entries))
+
+	color.Blue.Println("🔗 访问最多的URL")
+	showCounters(topN(urls, top), len(entries))
+
+	color.Blue.Println("🚦 状态码分布")
+	codes := topN(statuses, len(statuses))
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Key < codes[j].Key })
+	for _, code := range codes {
+		line := fmt.Sprintf("  %s  %8d  %5.1f%%", code.Key, code.Count, percent(code.Count, len(entries)))
+		switch code.Key[0] {
+		case '5':
+			color.Error.Println(line)
+		case '4':
+			color.Warn.Println(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+
+	showSlowest(entries, top)
+	showHistogram(entries)
+}
+
+// timeRange 返回最早和最晚的访问时间
+func timeRange(entries []Entry) (time.Time, time.Time) {
+	first, last := entries[0].Time, entries[0].Time
+	for _, entry := range entries {
+		if entry.Time.Before(first) {
+			first = entry.Time
+		}
+		if entry.Time.After(last) {
+			last = entry.Time
+		}
+	}
+
+	return first, last
+}
+
+func showCounters(counters []counter, total int) {
+	for _, c := range counters {
+		fmt.Printf("  %8d  %5.1f%%  %s\n", c.Count, percent(c.Count, total), c.Key)
+	}
+	fmt.Println()
+}
+
+func percent(count int, total int) float64 {
+	return float64(count) * 100 / float64(total)
+}
+
+// showSlowest 输出耗时最长的请求，需要日志格式中包含 $request_time
+func showSlowest(entries []Entry, top int) {
+	color.Blue.Println("🐢 最慢的请求")
+
+	slow := []Entry{}
+	for _, entry := range entries {
+		if entry.HasDuration {
+			slow = append(slow, entry)
+		}
+	}
+	if len(slow) == 0 {
+		color.Warnln("  日志中没有 $request_time 字段，无法统计耗时")
+		fmt.Println()
+		return
+	}
+
+	sort.SliceStable(slow, func(i, j int) bool { return slow[i].RequestTime > slow[j].RequestTime })
+	if len(slow) > top {
+		slow = slow[:top]
+	}
+	for _, entry := range slow {
+		fmt.Printf("  %7.3fs  %d  %s  %s %s\n", entry.RequestTime, entry.Status, entry.Time.Local().Format("01-02 15:04:05"), entry.Method, entry.URL)
+	}
+	fmt.Println()
+}
+
+// showHistogram 输出每分钟请求数，最多显示最近60分钟
+func showHistogram(entries []Entry) {
+	color.Blue.Println("📈 每分钟请求数")
+
+	minutes := map[int64]int{}
+	for _, entry := range entries {
+		minutes[entry.Time.Truncate(time.Minute).Unix()]++
+	}
+
+	start, end := timeRange(entries)
+	start, end = start.Truncate(time.Minute), end.Truncate(time.Minute)
+	if end.Sub(start) > 59*time.Minute {
+		start = end.Add(-59 * time.Minute)
+	}
+
+	max := 0
+	for t := start; !t.After(end); t = t.Add(time.Minute) {
+		if minutes[t.Unix()] > max {
+			max = minutes[t.Unix()]
+		}
+	}
+
+	for t := start; !t.After(end); t = t.Add(time.Minute) {
+		count := minutes[t.Unix()]
+		width := 0
+		if max > 0 {
+			width = count * 50 / max
+		}
+		fmt.Printf("  %s %6d %s\n", t.Local().Format("15:04"), count, color.Green.Render(strings.Repeat("█", width)))
+	}
+	fmt.Println()
+}
+
+// showErrors 输出错误日志的级别分布及最近的记录
+func showErrors(entries []ErrorEntry, last int) {
+	color.Blue.Println("❗ 错误日志")
+	if len(entries) == 0 {
+		fmt.Println("  没有错误记录")
+		fmt.Println()
+		return
+	}
+
+	levels := map[string]int{}
+	for _, entry := range entries {
+		levels[entry.Level]++
+	}
+	showCounters(topN(levels, len(levels)), len(entries))
+
+	if len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	for _, entry := range entries {
+		color.Warnln(fmt.Sprintf("  %s [%s] %s", entry.Time.Format("01-02 15:04:05"), entry.Level, entry.Message))
+	}
+	fmt.Println()
+}
+
+// showEntry 以tail的形式输出一条访问记录，按状态码染色
+func showEntry(entry Entry) {
+	line := fmt.Sprintf("%s %-15s %d %s %s", entry.Time.Local().Format("15:04:05"), entry.IP, entry.Status, entry.Method, entry.URL)
+	if entry.HasDuration {
+		line += fmt.Sprintf(" %.3fs", entry.RequestTime)
+	}
+
+	switch {
+	case entry.Status >= 500:
+		color.Error.Println(line)
+	case entry.Status >= 400:
+		color.Warn.Println(line)
+	default:
+		fmt.Println(line)
+	}
+}
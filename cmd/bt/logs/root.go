@@ -0,0 +1,178 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var LogsCmd = &cobra.Command{
+	Use:   "logs <site>",
+	Short: color.Blue.Render("分析网站的访问日志"),
+	Long:  color.Success.Render("\r\n分析网站的nginx访问日志与错误日志：访问最多的IP与URL、状态码分布、最慢的请求以及每分钟请求数"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		local, _ := cmd.Flags().GetBool("local")
+		dir, _ := cmd.Flags().GetString("dir")
+		since, _ := cmd.Flags().GetDuration("since")
+		top, _ := cmd.Flags().GetInt("top")
+		errors, _ := cmd.Flags().GetInt("errors")
+		follow, _ := cmd.Flags().GetBool("follow")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		fetch := func(path string) ([]byte, error) {
+			if local {
+				return os.ReadFile(path)
+			}
+
+			var out bytes.Buffer
+			link := host + "/download?filename=" + url.QueryEscape(path)
+			_, err := utils.Download(link, utils.PatchSign(key, url.Values{}), &out)
+			return out.Bytes(), err
+		}
+
+		accessPath := filepath.Join(dir, args[0]+".log")
+		errorPath := filepath.Join(dir, args[0]+".error.log")
+
+		from := time.Time{}
+		if since > 0 {
+			from = time.Now().Add(-since)
+		}
+
+		content, err := fetch(accessPath)
+		if err != nil {
+			return fmt.Errorf("读取访问日志 %s 失败：%s", accessPath, err)
+		}
+
+		if follow {
+			read := remoteTail(host, key, accessPath)
+			if local {
+				read = localTail(accessPath)
+			}
+			return followLog(read, content, from, interval)
+		}
+
+		entries, skipped := parseLog(bytes.NewReader(content), from)
+		showReport(entries, skipped, top)
+
+		if errors > 0 {
+			content, err := fetch(errorPath)
+			if err != nil {
+				color.Warnln("读取错误日志失败：", err)
+				return nil
+			}
+			showErrors(parseErrors(bytes.NewReader(content), from), errors)
+		}
+
+		return nil
+	},
+}
+
+// tail 读取日志从offset开始新增的内容，返回内容在文件中的起始位置，日志被切割后从头开始读取
+type tail func(offset int64) ([]byte, int64, error)
+
+// localTail 保持日志文件打开，只读取新增的部分，文件被替换后重新打开
+func localTail(path string) tail {
+	var file *os.File
+
+	return func(offset int64) ([]byte, int64, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, offset, err
+		}
+		if file != nil {
+			if opened, err := file.Stat(); err != nil || !os.SameFile(opened, info) {
+				file.Close()
+				file = nil
+				offset = 0
+			}
+		}
+		if file == nil {
+			if file, err = os.Open(path); err != nil {
+				return nil, offset, err
+			}
+		}
+		if info.Size() < offset {
+			offset = 0
+		}
+
+		content := make([]byte, info.Size()-offset)
+		n, err := file.ReadAt(content, offset)
+		if err == io.EOF {
+			err = nil
+		}
+
+		return content[:n], offset, err
+	}
+}
+
+// remoteTail 通过宝塔下载接口按Range只下载新增的部分
+func remoteTail(host string, key string, path string) tail {
+	link := host + "/download?filename=" + url.QueryEscape(path)
+
+	return func(offset int64) ([]byte, int64, error) {
+		var out bytes.Buffer
+		size, err := utils.DownloadRange(link, utils.PatchSign(key, url.Values{}), offset, &out)
+		// 只有确定文件变小（被轮转或清空）时才从头读取，总大小未知时保持当前位置
+		if err != nil || size < 0 || size >= offset {
+			return out.Bytes(), offset, err
+		}
+
+		out.Reset()
+		_, err = utils.DownloadRange(link, utils.PatchSign(key, url.Values{}), 0, &out)
+		return out.Bytes(), 0, err
+	}
+}
+
+// followLog 先输出since之后的记录，然后定期读取日志并输出新增的记录
+func followLog(read tail, content []byte, from time.Time, interval time.Duration) error {
+	// 只处理完整的行，不完整的行在下次读取
+	end := bytes.LastIndexByte(content, '\n') + 1
+	entries, _ := parseLog(bytes.NewReader(content[:end]), from)
+	for _, entry := range entries {
+		showEntry(entry)
+	}
+
+	offset := int64(end)
+	for {
+		time.Sleep(interval)
+
+		content, start, err := read(offset)
+		if err != nil {
+			color.Warnln("读取访问日志失败：", err)
+			continue
+		}
+		offset = start
+
+		end := bytes.LastIndexByte(content, '\n') + 1
+		if end == 0 {
+			continue
+		}
+
+		entries, _ := parseLog(bytes.NewReader(content[:end]), time.Time{})
+		for _, entry := range entries {
+			showEntry(entry)
+		}
+		offset += int64(end)
+	}
+}
+
+func init() {
+	LogsCmd.Flags().Bool("local", false, color.Blue.Render("读取本机的日志文件，而不是通过宝塔文件接口下载"))
+	LogsCmd.Flags().String("dir", "/www/wwwlogs", color.Blue.Render("日志目录"))
+	LogsCmd.Flags().Duration("since", 0, color.Blue.Render("只统计最近一段时间的记录，如：30m、2h"))
+	LogsCmd.Flags().Int("top", 10, color.Blue.Render("排行榜显示的条数"))
+	LogsCmd.Flags().Int("errors", 20, color.Blue.Render("显示最近N条错误日志，0表示不读取错误日志"))
+	LogsCmd.Flags().BoolP("follow", "f", false, color.Blue.Render("持续输出新增的访问记录"))
+	LogsCmd.Flags().Duration("interval", 2*time.Second, color.Blue.Render("持续输出时读取日志的间隔"))
+}
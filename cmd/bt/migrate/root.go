@@ -0,0 +1,130 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/site"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: color.Blue.Render("将网站迁移到另一台宝塔"),
+	Long:  color.Success.Render("\r\n在目标宝塔上重建网站（PHP版本、域名、伪静态、SSL），通过两端的文件接口传输网站文件及数据库备份，并列出需要修改的DNS记录。\r\n失败后重新执行相同的命令会从失败的步骤继续"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("site")
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		toKey, _ := cmd.Flags().GetString("to-key")
+		workdir, _ := cmd.Flags().GetString("workdir")
+		restart, _ := cmd.Flags().GetBool("restart")
+		wait, _ := cmd.Flags().GetDuration("wait")
+
+		if from == "" {
+			from, _ = cmd.Flags().GetString("host")
+		}
+		if from == to {
+			return errors.New("来源与目标是同一个宝塔")
+		}
+		if toKey == "" {
+			return errors.New("请通过 --to-key 指定目标宝塔的密钥")
+		}
+		if workdir == "" {
+			workdir = filepath.Join(os.TempDir(), "jarvis-migrate-"+name)
+		}
+		if err := os.MkdirAll(workdir, 0700); err != nil {
+			return err
+		}
+
+		p, err := loadProgress(name, from, to)
+		if err != nil {
+			return err
+		}
+		if restart {
+			p = newProgress(name, from, to)
+		}
+
+		fromKey, _ := cmd.Flags().GetString("key")
+		m := &migration{
+			from:     panel{from, fromKey},
+			to:       panel{to, toKey},
+			workdir:  workdir,
+			wait:     wait,
+			progress: p,
+		}
+
+		color.Blueln("读取源网站 " + name)
+		if err := m.inspect(name); err != nil {
+			return err
+		}
+		color.Infoln("路径：", m.source.Path, "PHP", m.php)
+		color.Infoln("域名：", m.domains)
+		for _, db := range m.databases {
+			color.Infoln("数据库：", db.Name)
+		}
+
+		for _, s := range m.steps() {
+			if p.Done[s.id] {
+				color.Infoln("✅ " + s.title + "（已完成，跳过）")
+				continue
+			}
+
+			color.Blueln("➡️  " + s.title)
+			if err := s.run(); err != nil {
+				return fmt.Errorf("%s失败：%s\r\n修复后重新执行相同的命令即可从该步骤继续", s.title, err)
+			}
+
+			p.Done[s.id] = true
+			if err := p.save(); err != nil {
+				return err
+			}
+			color.Infoln("✅ " + s.title)
+		}
+
+		// 迁移完成后删除本地中转的压缩包，进度保留，使用 --restart 重新迁移
+		for _, archive := range p.Archives {
+			os.Remove(archive)
+		}
+
+		color.Success.Println("\r\n迁移完成：" + name)
+		printDNS(m.domains, to)
+
+		return nil
+	},
+}
+
+// migration 一次迁移的上下文
+type migration struct {
+	from      panel
+	to        panel
+	workdir   string
+	wait      time.Duration
+	progress  *progress
+	source    site.SiteItem
+	php       string
+	domains   []string
+	databases []database.DatabaseItem
+}
+
+type panel struct {
+	host string
+	key  string
+}
+
+func init() {
+	MigrateCmd.Flags().String("site", "", color.Blue.Render("要迁移的网站名称"))
+	MigrateCmd.Flags().String("from", "", color.Blue.Render("源宝塔地址，默认使用 --host，密钥为 --key"))
+	MigrateCmd.Flags().String("to", "", color.Blue.Render("目标宝塔地址"))
+	MigrateCmd.Flags().String("to-key", "", color.Blue.Render("目标宝塔密钥"))
+	MigrateCmd.Flags().String("workdir", "", color.Blue.Render("本地中转目录，默认为系统临时目录"))
+	MigrateCmd.Flags().Bool("restart", false, color.Blue.Render("忽略上次的进度，从头开始迁移"))
+	MigrateCmd.Flags().Duration("wait", 30*time.Minute, color.Blue.Render("等待打包、解压及数据库备份完成的最长时间"))
+	MigrateCmd.MarkFlagRequired("site")
+	MigrateCmd.MarkFlagRequired("to")
+}
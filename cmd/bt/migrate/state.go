@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"jarvis/cmd/bt/utils"
+	"strings"
+)
+
+// progress 迁移进度，保存在本地以便失败后从中断的步骤继续
+type progress struct {
+	Site     string            `json:"site"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Done     map[string]bool   `json:"done"`
+	Archives map[string]string `json:"archives"`
+}
+
+func stateFile(site string) string {
+	return "migrate-" + strings.Replace(site, "/", "_", -1) + ".json"
+}
+
+// loadProgress 读取迁移进度，来源或目标面板不同时重新开始
+func loadProgress(site string, from string, to string) (*progress, error) {
+	p := &progress{}
+	if err := utils.LoadState(stateFile(site), p); err != nil {
+		return nil, err
+	}
+
+	if p.Site != site || p.From != from || p.To != to || p.Done == nil {
+		p = newProgress(site, from, to)
+	}
+	if p.Archives == nil {
+		p.Archives = map[string]string{}
+	}
+
+	return p, nil
+}
+
+func newProgress(site string, from string, to string) *progress {
+	return &progress{Site: site, From: from, To: to, Done: map[string]bool{}, Archives: map[string]string{}}
+}
+
+func (p *progress) save() error {
+	return utils.SaveState(stateFile(p.Site), p)
+}
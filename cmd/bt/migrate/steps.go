@@ -0,0 +1,307 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/site"
+	"jarvis/cmd/bt/utils"
+	"jarvis/cmd/shared"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+const (
+	rewriteDir = "/www/server/panel/vhost/rewrite/"
+	backupDir  = "/www/backup/"
+)
+
+// step 迁移中的一个步骤，id用于记录进度
+type step struct {
+	id    string
+	title string
+	run   func() error
+}
+
+// inspect 读取源网站的信息，每次执行都重新读取，不记录进度
+func (m *migration) inspect(name string) error {
+	source, err := site.Find(m.from.host, m.from.key, name)
+	if err != nil {
+		return err
+	}
+	m.source = source
+
+	if m.php, err = site.PHPVersion(m.from.host, m.from.key, name); err != nil {
+		return err
+	}
+
+	domains, err := site.Domains(m.from.host, m.from.key, source.Id)
+	if err != nil {
+		return err
+	}
+	m.domains = nil
+	for _, domain := range domains {
+		m.domains = append(m.domains, domain.Name)
+	}
+
+	m.databases, err = database.FindBySite(m.from.host, m.from.key, source.Id)
+
+	return err
+}
+
+// steps 按顺序列出迁移步骤，每个数据库单独备份与恢复
+func (m *migration) steps() []step {
+	steps := []step{
+		{"site", "创建网站", m.createSite},
+		{"rewrite", "复制伪静态规则", m.copyRewrite},
+		{"ssl", "部署SSL证书", m.copySSL},
+		{"files-pack", "打包网站文件", m.packFiles},
+		{"files-download", "下载网站文件", m.downloadFiles},
+		{"files-upload", "上传网站文件", m.uploadFiles},
+		{"files-extract", "解压网站文件", m.extractFiles},
+	}
+
+	for _, db := range m.databases {
+		db := db
+		steps = append(steps,
+			step{"db-backup:" + db.Name, "备份数据库 " + db.Name, func() error { return m.backupDatabase(db) }},
+			step{"db-restore:" + db.Name, "恢复数据库 " + db.Name, func() error { return m.restoreDatabase(db) }},
+		)
+	}
+
+	return steps
+}
+
+// createSite 在目标宝塔创建同名网站，已存在时只补充缺少的域名
+func (m *migration) createSite() error {
+	target, err := site.Find(m.to.host, m.to.key, m.source.Name)
+	if err != nil {
+		if _, err := site.Add(m.to.host, m.to.key, m.source.Name, m.source.Path, m.source.Ps, m.php); err != nil {
+			return err
+		}
+		if target, err = site.Find(m.to.host, m.to.key, m.source.Name); err != nil {
+			return err
+		}
+	}
+
+	existing, err := site.Domains(m.to.host, m.to.key, target.Id)
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, domain := range existing {
+		names[domain.Name] = true
+	}
+
+	var missing []string
+	for _, domain := range m.domains {
+		if !names[domain] {
+			missing = append(missing, domain)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return site.AddDomains(m.to.host, m.to.key, target.Id, target.Name, missing)
+}
+
+func (m *migration) copyRewrite() error {
+	file := rewriteDir + m.source.Name + ".conf"
+	rules, err := utils.ReadFile(m.from.host, m.from.key, file)
+	if err != nil {
+		color.Warnln("读取伪静态规则失败，已跳过：", err)
+		return nil
+	}
+
+	return utils.SaveFile(m.to.host, m.to.key, file, rules)
+}
+
+func (m *migration) copySSL() error {
+	ssl, err := site.SSL(m.from.host, m.from.key, m.source.Name)
+	if err != nil {
+		return err
+	}
+	if !ssl.Status || ssl.Key == "" || ssl.Csr == "" {
+		color.Warnln("源网站没有部署SSL证书，已跳过")
+		return nil
+	}
+
+	return site.SetSSL(m.to.host, m.to.key, m.source.Name, ssl.Csr, ssl.Key)
+}
+
+// archive 网站文件在两端宝塔上的临时压缩包
+func (m *migration) archive() string {
+	return backupDir + "migrate_" + m.source.Name + ".tar.gz"
+}
+
+// packFiles 在源宝塔打包网站目录，等待后台的压缩任务完成
+func (m *migration) packFiles() error {
+	archive := m.archive()
+
+	// 删除之前失败时留下的压缩包，避免把旧文件当作本次的结果
+	size, err := utils.FileSize(m.from.host, m.from.key, archive)
+	if err != nil {
+		return err
+	}
+	if size >= 0 {
+		if err := utils.DeleteFile(m.from.host, m.from.key, archive); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.Zip(m.from.host, m.from.key, path.Dir(m.source.Path), path.Base(m.source.Path), archive); err != nil {
+		return err
+	}
+	if err := utils.WaitTasks(m.from.host, m.from.key, archive, m.wait); err != nil {
+		return err
+	}
+
+	if size, err = utils.FileSize(m.from.host, m.from.key, archive); err != nil {
+		return err
+	}
+	if size <= 0 {
+		return errors.New("打包失败，源宝塔上的压缩包不存在或为空：" + archive)
+	}
+	color.Infoln("已打包：", archive, shared.FormatBytes(size))
+
+	return nil
+}
+
+// downloadFiles 下载压缩包，大小与源宝塔上的文件一致才算完成
+func (m *migration) downloadFiles() error {
+	archive := m.archive()
+	size, err := utils.FileSize(m.from.host, m.from.key, archive)
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		return errors.New("源宝塔上的压缩包不存在或为空，请重新打包：" + archive)
+	}
+
+	local, err := m.fetch(archive)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		os.Remove(local)
+		return fmt.Errorf("下载的压缩包大小 %d 与源宝塔上的 %d 不一致", info.Size(), size)
+	}
+	m.progress.Archives["site"] = local
+
+	return nil
+}
+
+func (m *migration) uploadFiles() error {
+	return utils.UploadFile(m.to.host, m.to.key, m.progress.Archives["site"], path.Dir(m.archive()))
+}
+
+// extractFiles 解压到目标网站的上级目录，完成后删除两端的临时压缩包
+func (m *migration) extractFiles() error {
+	archive := m.archive()
+	if err := utils.UnZip(m.to.host, m.to.key, archive, path.Dir(m.source.Path)); err != nil {
+		return err
+	}
+	// 解压同样在后台执行，完成前不能删除压缩包
+	if err := utils.WaitTasks(m.to.host, m.to.key, archive, m.wait); err != nil {
+		return err
+	}
+
+	for _, p := range []panel{m.from, m.to} {
+		if err := utils.DeleteFile(p.host, p.key, archive); err != nil {
+			color.Warnln("删除临时压缩包失败：", p.host, archive, err)
+		}
+	}
+
+	return nil
+}
+
+// backupDatabase 在源宝塔备份数据库并下载到本地
+func (m *migration) backupDatabase(db database.DatabaseItem) error {
+	backup, err := utils.RunBackup(m.from.host, m.from.key, utils.BackupDatabase, db.Id, m.wait)
+	if err != nil {
+		return err
+	}
+
+	local, err := m.fetch(backup.Filename)
+	if err != nil {
+		return err
+	}
+	m.progress.Archives["db:"+db.Name] = local
+
+	return nil
+}
+
+// restoreDatabase 上传备份，在目标宝塔创建同名数据库及用户并导入
+func (m *migration) restoreDatabase(db database.DatabaseItem) error {
+	local := m.progress.Archives["db:"+db.Name]
+	dir := backupDir + "database"
+	if err := utils.UploadFile(m.to.host, m.to.key, local, dir); err != nil {
+		return err
+	}
+
+	if _, err := database.Find(m.to.host, m.to.key, db.Name); err != nil {
+		if err := database.Add(m.to.host, m.to.key, db.Name, db.Username, db.Password); err != nil {
+			return err
+		}
+	}
+
+	return database.Import(m.to.host, m.to.key, db.Name, path.Join(dir, filepath.Base(local)))
+}
+
+// fetch 下载源宝塔上的文件到本地中转目录并校验压缩包
+func (m *migration) fetch(remote string) (string, error) {
+	local := filepath.Join(m.workdir, path.Base(remote))
+
+	file, err := os.Create(local + ".part")
+	if err != nil {
+		return "", err
+	}
+
+	link := m.from.host + "/download?filename=" + url.QueryEscape(remote)
+	size, err := utils.Download(link, utils.PatchSign(m.from.key, url.Values{}), file)
+	file.Close()
+	if err == nil && strings.HasSuffix(remote, ".gz") {
+		err = utils.VerifyGzip(local + ".part")
+	}
+	if err != nil {
+		os.Remove(local + ".part")
+		return "", err
+	}
+	color.Infoln("已下载：", local, shared.FormatBytes(size))
+
+	return local, os.Rename(local+".part", local)
+}
+
+// printDNS 列出需要指向目标服务器的域名
+func printDNS(domains []string, to string) {
+	address := to
+	if parsed, err := url.Parse(to); err == nil && parsed.Hostname() != "" {
+		address = parsed.Hostname()
+	}
+	if net.ParseIP(address) == nil {
+		if ips, err := net.LookupIP(address); err == nil && len(ips) > 0 {
+			address = ips[0].String()
+		}
+	}
+
+	fmt.Println()
+	color.Warnln("请修改以下DNS记录，指向目标服务器：")
+	for _, domain := range domains {
+		typ := "A"
+		if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+			typ = "AAAA"
+		}
+		fmt.Printf("  [ ] %-40s %-4s → %s\n", domain, typ, address)
+	}
+}
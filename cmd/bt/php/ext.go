@@ -0,0 +1,151 @@
+package php
+
+import (
+	"errors"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+type ExtItem struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Msg      string   `json:"msg"`
+	Versions []string `json:"versions"`
+	Status   bool     `json:"status"`
+}
+
+// Extensions 获取PHP版本可安装的扩展及安装状态
+func Extensions(host string, key string, version string) ([]ExtItem, error) {
+	link := host + "/ajax?action=GetPHPConfig"
+
+	var result struct {
+		Libs []ExtItem `json:"libs"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"version": {version},
+	}), &result)
+
+	return result.Libs, err
+}
+
+var ext = &cobra.Command{
+	Use:   "ext",
+	Short: "PHP扩展相关操作",
+	Long:  color.Success.Render("\r\nPHP扩展相关操作"),
+}
+
+var extList = &cobra.Command{
+	Use:   "list",
+	Short: "展示PHP扩展",
+	Long:  color.Success.Render("\r\n展示PHP版本可安装的扩展及安装状态"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		version, err := phpVersion(cmd)
+		if err != nil {
+			return err
+		}
+
+		items, err := Extensions(host, key, version)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if item.Status {
+				color.Infoln("✅", utils.StrPadRight(item.Name, 20, " "), item.Msg)
+			} else {
+				color.Println("  ", utils.StrPadRight(item.Name, 20, " "), item.Msg)
+			}
+		}
+
+		return nil
+	},
+}
+
+var extInstall = &cobra.Command{
+	Use:   "install <name>",
+	Short: "安装PHP扩展",
+	Long:  color.Success.Render("\r\n安装PHP扩展，安装在宝塔的任务队列中执行"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeExtension(cmd, args[0], true)
+	},
+}
+
+var extUninstall = &cobra.Command{
+	Use:   "uninstall <name>",
+	Short: "卸载PHP扩展",
+	Long:  color.Success.Render("\r\n卸载PHP扩展"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeExtension(cmd, args[0], false)
+	},
+}
+
+// changeExtension 安装或卸载扩展，先确认扩展存在且状态需要改变
+func changeExtension(cmd *cobra.Command, name string, install bool) error {
+	host, _ := cmd.Flags().GetString("host")
+	key, _ := cmd.Flags().GetString("key")
+	version, err := phpVersion(cmd)
+	if err != nil {
+		return err
+	}
+
+	items, err := Extensions(host, key, version)
+	if err != nil {
+		return err
+	}
+
+	var found *ExtItem
+	for i := range items {
+		if items[i].Name == name {
+			found = &items[i]
+			break
+		}
+	}
+	if found == nil {
+		return errors.New("PHP " + version + " 没有可用的扩展：" + name)
+	}
+	if found.Status && install {
+		color.Infoln("扩展已安装：" + name)
+		return nil
+	}
+	if !found.Status && !install {
+		color.Infoln("扩展未安装：" + name)
+		return nil
+	}
+
+	action := "UninstallSoft"
+	if install {
+		action = "InstallSoft"
+	}
+	link := host + "/files?action=" + action
+
+	err = utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"name":    {name},
+		"version": {version},
+		"type":    {"1"},
+	}), nil)
+	if err != nil {
+		return err
+	}
+
+	if install {
+		color.Infoln("已添加到安装队列：" + name + "，可在宝塔的消息盒子中查看进度")
+	} else {
+		color.Infoln("已卸载扩展：" + name)
+	}
+
+	return nil
+}
+
+func init() {
+	ext.AddCommand(extList)
+	ext.AddCommand(extInstall)
+	ext.AddCommand(extUninstall)
+}
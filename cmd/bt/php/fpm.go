@@ -0,0 +1,121 @@
+package php
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+type FpmConfig struct {
+	Pm              string `json:"pm"`
+	MaxChildren     int    `json:"max_children"`
+	StartServers    int    `json:"start_servers"`
+	MinSpareServers int    `json:"min_spare_servers"`
+	MaxSpareServers int    `json:"max_spare_servers"`
+	Allowed         string `json:"allowed"`
+}
+
+// validate 校验进程数之间的关系，与php-fpm启动时的检查一致
+func (c FpmConfig) validate() error {
+	switch c.Pm {
+	case "static", "ondemand":
+		if c.MaxChildren < 1 {
+			return errors.New("max_children 应大于0")
+		}
+		return nil
+	case "dynamic":
+	default:
+		return errors.New("pm 应为 static、dynamic 或 ondemand")
+	}
+
+	if c.MinSpareServers < 1 || c.MaxChildren < 1 {
+		return errors.New("max_children 与 min_spare_servers 应大于0")
+	}
+	if c.MinSpareServers > c.StartServers || c.StartServers > c.MaxSpareServers {
+		return errors.New("应满足 min_spare_servers <= start_servers <= max_spare_servers")
+	}
+	if c.MaxSpareServers > c.MaxChildren {
+		return errors.New("max_spare_servers 不能大于 max_children")
+	}
+
+	return nil
+}
+
+var fpm = &cobra.Command{
+	Use:   "fpm",
+	Short: "查看或修改php-fpm进程池",
+	Long:  color.Success.Render("\r\n查看php-fpm进程池配置，指定任一参数时修改配置"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		version, err := phpVersion(cmd)
+		if err != nil {
+			return err
+		}
+
+		var config FpmConfig
+		err = utils.PostJSON(host+"/config?action=getFpmConfig", utils.PatchSign(key, url.Values{
+			"version": {version},
+		}), &config)
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		if cmd.Flags().Changed("pm") {
+			config.Pm, _ = cmd.Flags().GetString("pm")
+			changed = true
+		}
+		for flag, field := range map[string]*int{
+			"max-children":      &config.MaxChildren,
+			"start-servers":     &config.StartServers,
+			"min-spare-servers": &config.MinSpareServers,
+			"max-spare-servers": &config.MaxSpareServers,
+		} {
+			if cmd.Flags().Changed(flag) {
+				*field, _ = cmd.Flags().GetInt(flag)
+				changed = true
+			}
+		}
+
+		if changed {
+			if err := config.validate(); err != nil {
+				return err
+			}
+
+			err = utils.PostJSON(host+"/config?action=setFpmConfig", utils.PatchSign(key, url.Values{
+				"version":           {version},
+				"pm":                {config.Pm},
+				"max_children":      {fmt.Sprint(config.MaxChildren)},
+				"start_servers":     {fmt.Sprint(config.StartServers)},
+				"min_spare_servers": {fmt.Sprint(config.MinSpareServers)},
+				"max_spare_servers": {fmt.Sprint(config.MaxSpareServers)},
+				"allowed":           {config.Allowed},
+			}), nil)
+			if err != nil {
+				return err
+			}
+			color.Infoln("已修改php-fpm进程池配置")
+		}
+
+		color.Infoln("pm                =", config.Pm)
+		color.Infoln("max_children      =", config.MaxChildren)
+		color.Infoln("start_servers     =", config.StartServers)
+		color.Infoln("min_spare_servers =", config.MinSpareServers)
+		color.Infoln("max_spare_servers =", config.MaxSpareServers)
+
+		return nil
+	},
+}
+
+func init() {
+	fpm.Flags().String("pm", "", color.Blue.Render("进程管理方式：static、dynamic、ondemand"))
+	fpm.Flags().Int("max-children", 0, color.Blue.Render("最大子进程数"))
+	fpm.Flags().Int("start-servers", 0, color.Blue.Render("启动时的子进程数"))
+	fpm.Flags().Int("min-spare-servers", 0, color.Blue.Render("最小空闲进程数"))
+	fpm.Flags().Int("max-spare-servers", 0, color.Blue.Render("最大空闲进程数"))
+}
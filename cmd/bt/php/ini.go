@@ -0,0 +1,216 @@
+package php
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// iniPath php.ini 在宝塔中的路径
+func iniPath(version string) string {
+	return "/www/server/php/" + version + "/etc/php.ini"
+}
+
+var sizePattern = regexp.MustCompile(`^(-1|\d+[KMG]?)$`)
+
+// validators 常用配置项的取值校验，未列出的配置项需要 --force
+var validators = map[string]func(string) error{
+	"memory_limit":                  validateSize,
+	"post_max_size":                 validateSize,
+	"upload_max_filesize":           validateSize,
+	"max_execution_time":            validateInt,
+	"max_input_time":                validateInt,
+	"max_input_vars":                validateInt,
+	"max_file_uploads":              validateInt,
+	"display_errors":                validateBool,
+	"display_startup_errors":        validateBool,
+	"log_errors":                    validateBool,
+	"short_open_tag":                validateBool,
+	"expose_php":                    validateBool,
+	"file_uploads":                  validateBool,
+	"allow_url_fopen":               validateBool,
+	"cgi.fix_pathinfo":              validateBool,
+	"opcache.enable":                validateBool,
+	"opcache.enable_cli":            validateBool,
+	"opcache.memory_consumption":    validateInt,
+	"opcache.max_accelerated_files": validateInt,
+	"opcache.revalidate_freq":       validateInt,
+	"date.timezone":                 validateTimezone,
+	"error_reporting":               validateNotEmpty,
+	"disable_functions":             validateAny,
+	"session.save_path":             validateAny,
+	"session.gc_maxlifetime":        validateInt,
+}
+
+func validateSize(value string) error {
+	if !sizePattern.MatchString(value) {
+		return errors.New("应为大小，如 128M、2G 或 -1")
+	}
+	return nil
+}
+
+func validateInt(value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return errors.New("应为整数")
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	switch strings.ToLower(value) {
+	case "on", "off", "1", "0", "true", "false", "yes", "no":
+		return nil
+	}
+	return errors.New("应为 On 或 Off")
+}
+
+func validateTimezone(value string) error {
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.New("无效的时区")
+	}
+	return nil
+}
+
+func validateNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("不能为空")
+	}
+	return nil
+}
+
+func validateAny(value string) error {
+	return nil
+}
+
+// iniLine 匹配配置项所在的行，包括被注释掉的
+func iniLine(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^[ \t]*(;?)[ \t]*` + regexp.QuoteMeta(name) + `[ \t]*=[ \t]*(.*?)[ \t]*$`)
+}
+
+// iniGet 读取生效的配置值
+func iniGet(content string, name string) (string, bool) {
+	for _, match := range iniLine(name).FindAllStringSubmatch(content, -1) {
+		if match[1] == "" {
+			return strings.Trim(match[2], `"`), true
+		}
+	}
+
+	return "", false
+}
+
+// iniSet 修改配置值：优先修改生效的行，其次取消注释，都没有时追加到文件末尾
+func iniSet(content string, name string, value string) string {
+	pattern := iniLine(name)
+	line := name + " = " + value
+
+	matches := pattern.FindAllStringSubmatchIndex(content, -1)
+	for _, match := range matches {
+		if match[3] == match[2] {
+			return content[:match[0]] + line + content[match[1]:]
+		}
+	}
+	if len(matches) > 0 {
+		match := matches[0]
+		return content[:match[0]] + line + content[match[1]:]
+	}
+
+	return strings.TrimRight(content, "\n") + "\n" + line + "\n"
+}
+
+var ini = &cobra.Command{
+	Use:   "ini",
+	Short: "php.ini相关操作",
+	Long:  color.Success.Render("\r\nphp.ini相关操作"),
+}
+
+var iniGetCmd = &cobra.Command{
+	Use:   "get <key>...",
+	Short: "读取php.ini配置",
+	Long:  color.Success.Render("\r\n读取php.ini中生效的配置值"),
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		version, err := phpVersion(cmd)
+		if err != nil {
+			return err
+		}
+
+		content, err := utils.ReadFile(host, key, iniPath(version))
+		if err != nil {
+			return err
+		}
+
+		for _, name := range args {
+			if value, ok := iniGet(content, name); ok {
+				color.Infoln(name + " = " + value)
+			} else {
+				color.Warnln(name + " 未设置")
+			}
+		}
+
+		return nil
+	},
+}
+
+var iniSetCmd = &cobra.Command{
+	Use:   "set <key=value>...",
+	Short: "修改php.ini配置",
+	Long:  color.Success.Render("\r\n校验取值后修改php.ini，并重载php-fpm"),
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		force, _ := cmd.Flags().GetBool("force")
+		version, err := phpVersion(cmd)
+		if err != nil {
+			return err
+		}
+
+		content, err := utils.ReadFile(host, key, iniPath(version))
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				return errors.New("格式应为 key=value：" + arg)
+			}
+			name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+			validate, known := validators[name]
+			if !known && !force {
+				return fmt.Errorf("不认识的配置项 %s，确认无误请加 --force", name)
+			}
+			if known {
+				if err := validate(value); err != nil {
+					return fmt.Errorf("%s 的取值无效：%s", name, err)
+				}
+			}
+
+			old, _ := iniGet(content, name)
+			content = iniSet(content, name, value)
+			color.Infoln(fmt.Sprintf("%s: %s -> %s", name, old, value))
+		}
+
+		if err := utils.SaveFile(host, key, iniPath(version), content); err != nil {
+			return err
+		}
+
+		return reload(host, key, version)
+	},
+}
+
+func init() {
+	ini.AddCommand(iniGetCmd)
+	ini.AddCommand(iniSetCmd)
+	iniSetCmd.Flags().Bool("force", false, color.Blue.Render("允许修改未做校验的配置项"))
+}
@@ -0,0 +1,55 @@
+package php
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var PhpCmd = &cobra.Command{
+	Use:   "php",
+	Short: color.Blue.Render("PHP相关操作"),
+	Long:  color.Success.Render("\r\nPHP扩展、php.ini及php-fpm进程池相关操作"),
+}
+
+var versionPattern = regexp.MustCompile(`^\d{2}$`)
+
+// phpVersion 读取 --version，8.1 与 81 均可
+func phpVersion(cmd *cobra.Command) (string, error) {
+	version, _ := cmd.Flags().GetString("version")
+	version = strings.Replace(version, ".", "", 1)
+
+	if !versionPattern.MatchString(version) {
+		return "", errors.New("请通过 --version 指定PHP版本，如：81")
+	}
+
+	return version, nil
+}
+
+// reload 重载php-fpm使配置生效
+func reload(host string, key string, version string) error {
+	link := host + "/system?action=ServiceAdmin"
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"name": {"php-fpm-" + version},
+		"type": {"reload"},
+	}), nil)
+	if err != nil {
+		return fmt.Errorf("重载php-fpm失败：%s", err)
+	}
+
+	return nil
+}
+
+func init() {
+	PhpCmd.AddCommand(ext)
+	PhpCmd.AddCommand(ini)
+	PhpCmd.AddCommand(fpm)
+	PhpCmd.PersistentFlags().String("version", "", color.Blue.Render("PHP版本，如：81"))
+}
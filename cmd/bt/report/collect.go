@@ -0,0 +1,137 @@
+package report
+
+import (
+	"fmt"
+	"jarvis/cmd/bt/crontab"
+	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/site"
+	"time"
+)
+
+// phpEOL PHP各版本停止安全维护的日期
+var phpEOL = map[string]string{
+	"52": "2011-01-06",
+	"53": "2014-08-14",
+	"54": "2015-09-03",
+	"55": "2016-07-21",
+	"56": "2018-12-31",
+	"70": "2019-01-10",
+	"71": "2019-12-01",
+	"72": "2020-11-30",
+	"73": "2021-12-06",
+	"74": "2022-11-28",
+	"80": "2023-11-26",
+	"81": "2025-12-31",
+	"82": "2026-12-31",
+	"83": "2027-12-31",
+	"84": "2028-12-31",
+}
+
+// isPHPEOL 判断PHP版本是否已停止维护，00表示纯静态
+func isPHPEOL(version string, now time.Time) bool {
+	date, ok := phpEOL[version]
+	if !ok {
+		return false
+	}
+	eol, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+
+	return now.After(eol)
+}
+
+// phpLabel 将80转换为8.0
+func phpLabel(version string) string {
+	switch {
+	case version == "" || version == "00":
+		return "静态"
+	case len(version) == 2:
+		return version[:1] + "." + version[1:]
+	}
+
+	return version
+}
+
+// siteRow 报告中的一个网站
+type siteRow struct {
+	Name        string
+	Path        string
+	Running     bool
+	PHP         string
+	PHPEOL      bool
+	SSL         bool
+	CertExpires time.Time
+	CertIssuer  string
+	CertWarning bool
+	Databases   []string
+	Warnings    []string
+}
+
+// inventory 一个宝塔面板的资产清单
+type inventory struct {
+	Host      string
+	Generated time.Time
+	Sites     []siteRow
+	Databases []database.DatabaseItem
+	Crontabs  []crontab.CrontabItem
+}
+
+// collect 通过宝塔接口收集网站、数据库、计划任务及证书信息
+func collect(host string, key string, days int) (inventory, error) {
+	now := time.Now()
+	inv := inventory{Host: host, Generated: now}
+
+	var err error
+	if inv.Databases, err = database.Get(host, key); err != nil {
+		return inv, err
+	}
+	if inv.Crontabs, err = crontab.Get(host, key); err != nil {
+		return inv, err
+	}
+
+	sites, err := site.Get(host, key)
+	if err != nil {
+		return inv, err
+	}
+
+	for _, item := range sites {
+		row := siteRow{Name: item.Name, Path: item.Path, Running: item.Status == "1"}
+
+		if row.PHP, err = site.PHPVersion(host, key, item.Name); err != nil {
+			return inv, err
+		}
+		row.PHPEOL = isPHPEOL(row.PHP, now)
+		if row.PHPEOL {
+			row.Warnings = append(row.Warnings, fmt.Sprintf("PHP %s 已停止维护", phpLabel(row.PHP)))
+		}
+
+		ssl, err := site.SSL(host, key, item.Name)
+		if err != nil {
+			return inv, err
+		}
+		row.SSL = ssl.Status
+		row.CertExpires = ssl.Expires()
+		row.CertIssuer = ssl.CertData.Issuer
+
+		switch {
+		case !row.SSL:
+			row.CertWarning = true
+			row.Warnings = append(row.Warnings, "未开启SSL")
+		case row.CertExpires.IsZero():
+		case row.CertExpires.Before(now):
+			row.CertWarning = true
+			row.Warnings = append(row.Warnings, "证书已过期")
+		case row.CertExpires.Before(now.AddDate(0, 0, days)):
+			row.CertWarning = true
+			row.Warnings = append(row.Warnings, fmt.Sprintf("证书将在 %d 天内到期", int(row.CertExpires.Sub(now).Hours()/24)+1))
+		}
+
+		for _, db := range inv.Databases {
+			if db.Pid == item.Id {
+				row.Databases = append(row.Databases, db.Name)
+			}
+		}
+
+		inv.Sites = append(inv.Sites, row)
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+func certLabel(row siteRow) string {
+	if !row.SSL {
+		return "未开启"
+	}
+	if row.CertExpires.IsZero() {
+		return "已开启"
+	}
+
+	return row.CertExpires.Format("2006-01-02")
+}
+
+func runningLabel(running bool) string {
+	if running {
+		return "运行中"
+	}
+
+	return "已停止"
+}
+
+// renderMarkdown 输出Markdown格式的报告，需要关注的项目加粗显示
+func renderMarkdown(w io.Writer, inv inventory) error {
+	fmt.Fprintf(w, "# 宝塔资产清单\n\n")
+	fmt.Fprintf(w, "- 面板：%s\n- 生成时间：%s\n- 网站：%d，数据库：%d，计划任务：%d\n\n",
+		inv.Host, inv.Generated.Format("2006-01-02 15:04:05"), len(inv.Sites), len(inv.Databases), len(inv.Crontabs))
+
+	fmt.Fprintf(w, "## 网站\n\n")
+	fmt.Fprintf(w, "| 网站 | 状态 | PHP | SSL证书 | 数据库 | 路径 | 需要关注 |\n")
+	fmt.Fprintf(w, "| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, row := range inv.Sites {
+		php := phpLabel(row.PHP)
+		if row.PHPEOL {
+			php = "**" + php + "**"
+		}
+		cert := certLabel(row)
+		if row.CertWarning {
+			cert = "**" + cert + "**"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			mdEscape(row.Name), runningLabel(row.Running), php, cert, mdEscape(strings.Join(row.Databases, ", ")),
+			mdEscape(row.Path), mdEscape(strings.Join(row.Warnings, "；")))
+	}
+
+	fmt.Fprintf(w, "\n## 数据库\n\n")
+	fmt.Fprintf(w, "| 数据库 | 用户 | 访问权限 | 备注 |\n")
+	fmt.Fprintf(w, "| --- | --- | --- | --- |\n")
+	for _, db := range inv.Databases {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", mdEscape(db.Name), mdEscape(db.Username), mdEscape(db.Accept), mdEscape(db.Ps))
+	}
+
+	fmt.Fprintf(w, "\n## 计划任务\n\n")
+	fmt.Fprintf(w, "| ID | 名称 | 周期 |\n")
+	fmt.Fprintf(w, "| --- | --- | --- |\n")
+	for _, item := range inv.Crontabs {
+		fmt.Fprintf(w, "| %d | %s | %s |\n", item.Id, mdEscape(item.Name), mdEscape(item.Type))
+	}
+
+	return nil
+}
+
+func mdEscape(value string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(value)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"php":     phpLabel,
+	"cert":    certLabel,
+	"running": runningLabel,
+	"join":    strings.Join,
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>宝塔资产清单 - {{.Host}}</title>
+<style>
+body { font-family: -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; margin: 2em; color: #333; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; }
+th { background: #f5f5f5; }
+.warn { background: #fff3cd; }
+.bad { color: #c0392b; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>宝塔资产清单</h1>
+<p>面板：{{.Host}}<br>生成时间：{{date .Generated}}<br>网站：{{len .Sites}}，数据库：{{len .Databases}}，计划任务：{{len .Crontabs}}</p>
+
+<h2>网站</h2>
+<table>
+<tr><th>网站</th><th>状态</th><th>PHP</th><th>SSL证书</th><th>数据库</th><th>路径</th><th>需要关注</th></tr>
+{{- range .Sites}}
+<tr{{if .Warnings}} class="warn"{{end}}>
+<td>{{.Name}}</td>
+<td>{{running .Running}}</td>
+<td{{if .PHPEOL}} class="bad"{{end}}>{{php .PHP}}</td>
+<td{{if .CertWarning}} class="bad"{{end}}>{{cert .}}</td>
+<td>{{join .Databases ", "}}</td>
+<td>{{.Path}}</td>
+<td class="bad">{{join .Warnings "；"}}</td>
+</tr>
+{{- end}}
+</table>
+
+<h2>数据库</h2>
+<table>
+<tr><th>数据库</th><th>用户</th><th>访问权限</th><th>备注</th></tr>
+{{- range .Databases}}
+<tr><td>{{.Name}}</td><td>{{.Username}}</td><td>{{.Accept}}</td><td>{{.Ps}}</td></tr>
+{{- end}}
+</table>
+
+<h2>计划任务</h2>
+<table>
+<tr><th>ID</th><th>名称</th><th>周期</th></tr>
+{{- range .Crontabs}}
+<tr><td>{{.Id}}</td><td>{{.Name}}</td><td>{{.Type}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// renderHTML 输出HTML格式的报告，需要关注的网站以底色标出
+func renderHTML(w io.Writer, inv inventory) error {
+	return htmlTemplate.Execute(w, inv)
+}
+
+// renderCSV 输出CSV格式的报告，第一列区分记录类型
+func renderCSV(w io.Writer, inv inventory) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"type", "name", "status", "php", "php_eol", "ssl", "cert_expires", "databases", "path", "warnings"})
+
+	for _, row := range inv.Sites {
+		expires := ""
+		if !row.CertExpires.IsZero() {
+			expires = row.CertExpires.Format("2006-01-02")
+		}
+		writer.Write([]string{"site", row.Name, runningLabel(row.Running), phpLabel(row.PHP), fmt.Sprint(row.PHPEOL), fmt.Sprint(row.SSL),
+			expires, strings.Join(row.Databases, " "), row.Path, strings.Join(row.Warnings, "；")})
+	}
+	for _, db := range inv.Databases {
+		writer.Write([]string{"database", db.Name, "", "", "", "", "", "", "", ""})
+	}
+	for _, item := range inv.Crontabs {
+		writer.Write([]string{"crontab", item.Name, item.Type, "", "", "", "", "", "", ""})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var ReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: color.Blue.Render("生成资产清单报告"),
+	Long:  color.Success.Render("\r\n汇总网站、PHP版本、数据库、计划任务及证书到期时间，标出已停止维护的PHP、未开启SSL及即将到期的证书"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		days, _ := cmd.Flags().GetInt("days")
+
+		renderers := map[string]func(io.Writer, inventory) error{
+			"md":   renderMarkdown,
+			"html": renderHTML,
+			"csv":  renderCSV,
+		}
+		render, ok := renderers[format]
+		if !ok {
+			return errors.New("不支持的格式：" + format + "，可选 md、html、csv")
+		}
+
+		inv, err := collect(host, key, days)
+		if err != nil {
+			return err
+		}
+
+		if output == "" {
+			return render(os.Stdout, inv)
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := render(file, inv); err != nil {
+			return err
+		}
+		color.Infoln("报告已保存到 " + output)
+
+		return nil
+	},
+}
+
+func init() {
+	ReportCmd.Flags().String("format", "md", color.Blue.Render("报告格式：md、html、csv"))
+	ReportCmd.Flags().StringP("output", "o", "", color.Blue.Render("保存到文件，默认输出到终端"))
+	ReportCmd.Flags().Int("days", 30, color.Blue.Render("证书在N天内到期时提醒"))
+}
@@ -2,9 +2,19 @@ package bt
 
 import (
 	"errors"
+	"jarvis/cmd/bt/audit"
+	"jarvis/cmd/bt/backup"
 	"jarvis/cmd/bt/crontab"
 	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/firewall"
+	"jarvis/cmd/bt/ftp"
+	"jarvis/cmd/bt/logs"
+	"jarvis/cmd/bt/migrate"
+	"jarvis/cmd/bt/php"
+	"jarvis/cmd/bt/report"
 	"jarvis/cmd/bt/site"
+	"jarvis/cmd/bt/soft"
+	"jarvis/cmd/bt/utils"
 
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
@@ -15,8 +25,15 @@ var BtCmd = &cobra.Command{
 	Long:  color.Success.Render("\r\n宝塔管理工具。"),
 	Short: color.Blue.Render("宝塔相关操作"),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 只读取本机文件的命令不需要访问宝塔
+		if local, err := cmd.Flags().GetBool("local"); err == nil && local {
+			return nil
+		}
+
 		host, _ := cmd.Flags().GetString("host")
 		key, _ := cmd.Flags().GetString("key")
+		record, _ := cmd.Flags().GetString("record")
+		replay, _ := cmd.Flags().GetString("replay")
 
 		color.Blueln("\r\n宝塔API地址：" + host + "\r\n")
 
@@ -24,6 +41,23 @@ var BtCmd = &cobra.Command{
 			return errors.New(color.Error.Renderln("请输入宝塔地址") + "\r\n")
 		}
 
+		if record != "" && replay != "" {
+			return errors.New(color.Error.Renderln("--record 与 --replay 不能同时使用") + "\r\n")
+		}
+
+		if record != "" {
+			if err := utils.Record(record); err != nil {
+				return err
+			}
+			color.Blueln("录制请求到：" + record + "\r\n")
+		}
+
+		// 回放时不访问网络，不需要密钥
+		if replay != "" {
+			color.Blueln("回放录制的请求：" + replay + "\r\n")
+			return utils.Replay(replay)
+		}
+
 		if key == "" {
 			return errors.New(color.Red.Renderln("请输入宝塔密钥") + "\r\n")
 		}
@@ -37,6 +71,17 @@ func init() {
 	BtCmd.AddCommand(crontab.CrontabCmd)
 	BtCmd.AddCommand(site.SiteCmd)
 	BtCmd.AddCommand(database.DatabaseCmd)
+	BtCmd.AddCommand(backup.BackupCmd)
+	BtCmd.AddCommand(audit.AuditCmd)
+	BtCmd.AddCommand(logs.LogsCmd)
+	BtCmd.AddCommand(firewall.FirewallCmd)
+	BtCmd.AddCommand(ftp.FtpCmd)
+	BtCmd.AddCommand(report.ReportCmd)
+	BtCmd.AddCommand(soft.SoftCmd)
+	BtCmd.AddCommand(php.PhpCmd)
+	BtCmd.AddCommand(migrate.MigrateCmd)
 	BtCmd.PersistentFlags().StringP("host", "s", "http://127.0.0.1:8888", color.Blue.Render("宝塔地址"))
 	BtCmd.PersistentFlags().StringP("key", "k", "", color.Blue.Render("宝塔密钥"))
+	BtCmd.PersistentFlags().String("record", "", color.Blue.Render("将请求及响应录制到目录，签名字段会被脱敏"))
+	BtCmd.PersistentFlags().String("replay", "", color.Blue.Render("不访问网络，回放目录中录制的响应"))
 }
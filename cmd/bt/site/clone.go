@@ -0,0 +1,285 @@
+package site
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/database"
+	"jarvis/cmd/bt/utils"
+	"jarvis/cmd/shared"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var clone = &cobra.Command{
+	Use:   "clone",
+	Short: "克隆网站到新域名",
+	Long:  color.Success.Render("\r\n克隆网站到新域名：复制文件、配置文件、伪静态及数据库，并更新项目中的数据库配置"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		toPath, _ := cmd.Flags().GetString("path")
+		noDb, _ := cmd.Flags().GetBool("no-db")
+		wait, _ := cmd.Flags().GetDuration("wait")
+
+		source, err := Find(host, key, from)
+		if err != nil {
+			return err
+		}
+		if toPath == "" {
+			toPath = "/www/wwwroot/" + to
+		}
+
+		version, err := PHPVersion(host, key, from)
+		if err != nil {
+			return err
+		}
+		color.Infoln("源网站：", source.Name, source.Path, "PHP", version)
+
+		color.Blueln("复制网站文件到 " + toPath)
+		if err := utils.CopyFile(host, key, source.Path, toPath); err != nil {
+			return fmt.Errorf("复制网站文件失败：%s", err)
+		}
+
+		color.Blueln("创建网站 " + to)
+		if _, err := Add(host, key, to, toPath, "克隆自 "+from, version); err != nil {
+			// 宝塔复制目录时目标不能已存在，只能先复制文件，创建失败时删除复制的文件
+			if err := utils.DeleteDir(host, key, toPath); err != nil {
+				color.Warnln("删除已复制的文件失败，请手动删除：", toPath, err)
+			} else {
+				color.Warnln("创建网站失败，已删除复制的文件：" + toPath)
+			}
+			return err
+		}
+
+		color.Blueln("复制网站配置")
+		if err := cloneVhost(host, key, from, to, source.Path, toPath); err != nil {
+			return err
+		}
+
+		if !noDb {
+			if err := cloneDatabase(cmd, source, to, toPath, wait); err != nil {
+				return err
+			}
+		}
+
+		color.Success.Println("\r\n克隆完成：" + to)
+		color.Warnln("SSL证书未复制，请为新域名重新申请证书")
+
+		return nil
+	},
+}
+
+// cloneVhost 复制nginx配置与伪静态规则，并替换域名、根目录及日志路径
+func cloneVhost(host string, key string, from string, to string, fromPath string, toPath string) error {
+	vhost := "/www/server/panel/vhost/nginx/"
+	conf, err := utils.ReadFile(host, key, vhost+from+".conf")
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败：%s", err)
+	}
+	if err := utils.SaveFile(host, key, vhost+to+".conf", rewriteVhost(conf, from, to, fromPath, toPath)); err != nil {
+		return fmt.Errorf("保存配置文件失败：%s", err)
+	}
+
+	rewrite := "/www/server/panel/vhost/rewrite/"
+	rules, err := utils.ReadFile(host, key, rewrite+from+".conf")
+	if err != nil {
+		color.Warnln("读取伪静态规则失败，已跳过：", err)
+		return nil
+	}
+
+	return utils.SaveFile(host, key, rewrite+to+".conf", rules)
+}
+
+// rewriteVhost 替换server_name、root、日志及include中的域名和路径，其余内容保持不变
+func rewriteVhost(conf string, from string, to string, fromPath string, toPath string) string {
+	directives := []string{"server_name", "root", "access_log", "error_log", "include"}
+	replacer := strings.NewReplacer(fromPath, toPath, from, to)
+	lines := strings.Split(conf, "\n")
+
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, directive := range directives {
+			if fields[0] == directive {
+				lines[i] = replacer.Replace(line)
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// cloneDatabase 备份源数据库，导入到新建的数据库，并更新项目配置文件
+func cloneDatabase(cmd *cobra.Command, source SiteItem, to string, toPath string, wait time.Duration) error {
+	host, _ := cmd.Flags().GetString("host")
+	key, _ := cmd.Flags().GetString("key")
+	dbName, _ := cmd.Flags().GetString("db")
+	name, _ := cmd.Flags().GetString("db-name")
+	user, _ := cmd.Flags().GetString("db-user")
+	password, _ := cmd.Flags().GetString("db-password")
+
+	var src database.DatabaseItem
+	if dbName != "" {
+		item, err := database.Find(host, key, dbName)
+		if err != nil {
+			return err
+		}
+		src = item
+	} else {
+		related, err := database.FindBySite(host, key, source.Id)
+		if err != nil {
+			return err
+		}
+		if len(related) == 0 {
+			color.Warnln("源网站没有关联的数据库，已跳过数据库复制")
+			return nil
+		}
+		if len(related) > 1 {
+			return errors.New("源网站关联了多个数据库，请通过 --db 指定")
+		}
+		src = related[0]
+	}
+
+	if name == "" {
+		name = identifierFor(to)
+	}
+	if user == "" {
+		user = name
+	}
+	if password == "" {
+		password = shared.RandomString(16)
+	}
+
+	color.Blueln("备份源数据库 " + src.Name)
+	backup, err := utils.RunBackup(host, key, utils.BackupDatabase, src.Id, wait)
+	if err != nil {
+		return fmt.Errorf("备份源数据库失败：%s", err)
+	}
+
+	color.Blueln("创建数据库 " + name)
+	if err := database.Add(host, key, name, user, password); err != nil {
+		return fmt.Errorf("创建数据库失败：%s", err)
+	}
+
+	color.Blueln("导入数据")
+	if err := database.Import(host, key, name, backup.Filename); err != nil {
+		return fmt.Errorf("导入数据失败：%s", err)
+	}
+
+	patchConfigs(host, key, toPath, name, user, password, src)
+
+	color.Infoln("数据库：", name)
+	color.Infoln("用户名：", user)
+	color.Infoln("密码：", password)
+
+	return nil
+}
+
+// patchConfigs 更新常见项目配置文件中的数据库名称、用户名和密码
+func patchConfigs(host string, key string, root string, name string, user string, password string, src database.DatabaseItem) {
+	patches := []struct {
+		file  string
+		patch func(string, string, string, string) string
+	}{
+		{path.Join(root, ".env"), patchEnv},
+		{path.Join(root, "wp-config.php"), patchWpConfig},
+	}
+
+	for _, p := range patches {
+		content, err := utils.ReadFile(host, key, p.file)
+		// 只处理确实引用了源数据库的配置
+		if err != nil || !strings.Contains(content, src.Name) {
+			continue
+		}
+		if err := utils.SaveFile(host, key, p.file, p.patch(content, name, user, password)); err != nil {
+			color.Warnln("更新配置文件失败：", p.file, err)
+			continue
+		}
+		color.Infoln("已更新配置文件：", p.file)
+	}
+}
+
+var envPattern = regexp.MustCompile(`(?m)^(DB_DATABASE|DB_USERNAME|DB_PASSWORD)=.*$`)
+
+func patchEnv(content string, name string, user string, password string) string {
+	values := map[string]string{
+		"DB_DATABASE": name,
+		"DB_USERNAME": user,
+		"DB_PASSWORD": password,
+	}
+
+	return envPattern.ReplaceAllStringFunc(content, func(line string) string {
+		field := strings.SplitN(line, "=", 2)[0]
+		return field + "=" + envQuote(values[field])
+	})
+}
+
+// envQuote 为 .env 中的值加引号，单引号内的 #、空格及 $ 都按原样读取，
+// 值中包含单引号时改用双引号，并转义反斜杠及双引号
+func envQuote(value string) string {
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// wpPattern 匹配 wp-config.php 中的数据库配置，原值可以包含转义的引号
+var wpPattern = regexp.MustCompile(`define\(\s*['"](DB_NAME|DB_USER|DB_PASSWORD)['"]\s*,\s*(?:'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")\s*\)`)
+
+func patchWpConfig(content string, name string, user string, password string) string {
+	values := map[string]string{
+		"DB_NAME":     name,
+		"DB_USER":     user,
+		"DB_PASSWORD": password,
+	}
+
+	return wpPattern.ReplaceAllStringFunc(content, func(match string) string {
+		field := wpPattern.FindStringSubmatch(match)[1]
+		// PHP 单引号字符串只需转义反斜杠及单引号
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(values[field])
+
+		return fmt.Sprintf("define( '%s', '%s' )", field, value)
+	})
+}
+
+var identifierPattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// identifierFor 由域名生成数据库名称，不超过旧版 MySQL 用户名的16个字符。
+// 过长时截断并加上域名摘要的前5位，如 staging.example.com 生成 staging_ex_0f710
+func identifierFor(domain string) string {
+	name := strings.Trim(identifierPattern.ReplaceAllString(domain, "_"), "_")
+	if len(name) <= 16 {
+		return name
+	}
+
+	sum := sha1.Sum([]byte(domain))
+
+	return strings.TrimRight(name[:10], "_") + "_" + hex.EncodeToString(sum[:])[:5]
+}
+
+func init() {
+	clone.Flags().String("from", "", color.Blue.Render("源网站名称"))
+	clone.Flags().String("to", "", color.Blue.Render("新网站的域名"))
+	clone.Flags().String("path", "", color.Blue.Render("新网站的路径，默认为 /www/wwwroot/<域名>"))
+	clone.Flags().String("db", "", color.Blue.Render("源数据库名称，默认使用源网站关联的数据库"))
+	clone.Flags().String("db-name", "", color.Blue.Render("新数据库名称，默认由域名生成"))
+	clone.Flags().String("db-user", "", color.Blue.Render("新数据库用户名，默认与数据库名称相同"))
+	clone.Flags().String("db-password", "", color.Blue.Render("新数据库密码，默认随机生成"))
+	clone.Flags().Bool("no-db", false, color.Blue.Render("不复制数据库"))
+	clone.Flags().Duration("wait", 30*time.Minute, color.Blue.Render("等待数据库备份完成的最长时间"))
+	clone.MarkFlagRequired("from")
+	clone.MarkFlagRequired("to")
+}
@@ -2,7 +2,7 @@ package site
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
 	"jarvis/cmd/bt/utils"
@@ -40,36 +40,57 @@ var Create = &cobra.Command{
 		domain, _ := cmd.Flags().GetString("domain")
 		comment, _ := cmd.Flags().GetString("comment")
 		path, _ := cmd.Flags().GetString("path")
-		link := host + "/site?action=AddSite"
 
 		if path == "" {
 			path = "/www/wwwroot/" + domain
 		}
 
-		webname, err := json.Marshal(Webname{
-			Domain:     domain,
-			Domainlist: "[]",
-			Count:      0,
-		})
+		result, err := Add(host, key, domain, path, comment, "80")
 		if err != nil {
-			fmt.Println("生成json失败", err)
+			color.Errorln(err.Error())
+			return
 		}
-
-		result := utils.Post(link, utils.PatchSign(key, url.Values{
-			"webname": {string(webname)},
-			"path":    {path},
-			"type_id": {"0"},
-			"type":    {"PHP"},
-			"version": {"80"},
-			"port":    {"80"},
-			"ps":      {comment},
-			"ftp":     {"false"},
-			"sql":     {"false"},
-		}))
-		color.Infoln(result)
+		color.Infoln("网站创建成功，ID：", result.SiteId)
 	},
 }
 
+type AddResult struct {
+	SiteStatus bool `json:"siteStatus"`
+	SiteId     int  `json:"siteId"`
+}
+
+// Add 创建PHP网站，version为PHP版本，如80
+func Add(host string, key string, domain string, path string, comment string, version string) (AddResult, error) {
+	var result AddResult
+	link := host + "/site?action=AddSite"
+
+	webname, err := json.Marshal(Webname{
+		Domain:     domain,
+		Domainlist: "[]",
+		Count:      0,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"webname": {string(webname)},
+		"path":    {path},
+		"type_id": {"0"},
+		"type":    {"PHP"},
+		"version": {version},
+		"port":    {"80"},
+		"ps":      {comment},
+		"ftp":     {"false"},
+		"sql":     {"false"},
+	}), &result)
+	if err == nil && !result.SiteStatus {
+		err = errors.New("创建网站失败")
+	}
+
+	return result, err
+}
+
 func init() {
 	Create.Flags().String("domain", "", color.Blue.Render("要新建的网站的域名"))
 	Create.Flags().String("comment", "", color.Blue.Render("要新建的网站的备注"))
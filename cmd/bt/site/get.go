@@ -0,0 +1,93 @@
+package site
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+	"strings"
+)
+
+type SiteItem struct {
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	Ps      string `json:"ps"`
+	AddTime string `json:"addtime"`
+	Edate   string `json:"edate"`
+}
+
+// Get 获取网站列表
+func Get(host string, key string) ([]SiteItem, error) {
+	link := host + "/data?action=getData&table=sites"
+
+	var result struct {
+		Data []SiteItem `json:"data"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"limit": {"1000"},
+		"p":     {"1"},
+	}), &result)
+
+	return result.Data, err
+}
+
+// Find 按名称查找网站
+func Find(host string, key string, name string) (SiteItem, error) {
+	items, err := Get(host, key)
+	if err != nil {
+		return SiteItem{}, err
+	}
+
+	for _, item := range items {
+		if item.Name == name {
+			return item, nil
+		}
+	}
+
+	return SiteItem{}, errors.New("找不到网站：" + name)
+}
+
+type DomainItem struct {
+	Id   int    `json:"id"`
+	Pid  int    `json:"pid"`
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// Domains 获取网站绑定的域名
+func Domains(host string, key string, id int) ([]DomainItem, error) {
+	link := host + "/data?action=getData&table=domain"
+
+	var result struct {
+		Data []DomainItem `json:"data"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"search": {fmt.Sprint(id)},
+		"limit":  {"1000"},
+		"p":      {"1"},
+	}), &result)
+
+	domains := []DomainItem{}
+	for _, item := range result.Data {
+		if item.Pid == id {
+			domains = append(domains, item)
+		}
+	}
+
+	return domains, err
+}
+
+// AddDomains 为网站添加域名
+func AddDomains(host string, key string, id int, name string, domains []string) error {
+	link := host + "/site?action=AddDomain"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"id":       {fmt.Sprint(id)},
+		"webname":  {strings.Join(domains, ",")},
+		"siteName": {name},
+	}), nil)
+}
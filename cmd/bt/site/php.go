@@ -21,3 +21,18 @@ var php = &cobra.Command{
 		color.Infoln(result)
 	},
 }
+
+// PHPVersion 获取网站使用的PHP版本，如80
+func PHPVersion(host string, key string, name string) (string, error) {
+	link := host + "/site?action=GetSitePHPVersion"
+
+	var result struct {
+		PHPVersion string `json:"phpversion"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"siteName": {name},
+	}), &result)
+
+	return result.PHPVersion, err
+}
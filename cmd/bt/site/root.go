@@ -18,4 +18,5 @@ func init() {
 	SiteCmd.AddCommand(delete)
 	SiteCmd.AddCommand(Create)
 	SiteCmd.AddCommand(Conf)
+	SiteCmd.AddCommand(clone)
 }
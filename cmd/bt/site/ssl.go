@@ -0,0 +1,68 @@
+package site
+
+import (
+	"encoding/json"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type SSLInfo struct {
+	Status   bool   `json:"status"`
+	Key      string `json:"key"`
+	Csr      string `json:"csr"`
+	CertData struct {
+		Issuer   string   `json:"issuer"`
+		NotAfter string   `json:"notAfter"`
+		DNS      []string `json:"dns"`
+	} `json:"cert_data"`
+}
+
+// Expires 证书到期时间，没有证书时返回零值
+func (s SSLInfo) Expires() time.Time {
+	value := strings.TrimSpace(s.CertData.NotAfter)
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// SetSSL 为网站部署证书，csr为证书内容，key为私钥
+func SetSSL(host string, key string, name string, csr string, privateKey string) error {
+	link := host + "/site?action=SetSSL"
+
+	return utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"type":     {"1"},
+		"siteName": {name},
+		"key":      {privateKey},
+		"csr":      {csr},
+	}), nil)
+}
+
+// SSL 获取网站的SSL配置及证书信息
+func SSL(host string, key string, name string) (SSLInfo, error) {
+	link := host + "/site?action=GetSSL"
+
+	var result SSLInfo
+
+	// 未开启SSL时宝塔同样返回 status=false，不能交给 PostJSON 判断
+	content, err := utils.PostE(link, utils.PatchSign(key, url.Values{
+		"siteName": {name},
+	}))
+	if err != nil {
+		return result, err
+	}
+
+	// 不同版本的宝塔 cert_data 中字段类型不尽相同，类型不符的字段忽略即可
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); !ok {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package soft
+
+import (
+	"encoding/json"
+	"jarvis/cmd/bt/utils"
+	"net/url"
+)
+
+type SoftItem struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Version  string `json:"version"`
+	Setup    bool   `json:"setup"`
+	Status   bool   `json:"status"`
+	Versions []struct {
+		MVersion string `json:"m_version"`
+		Version  string `json:"version"`
+	} `json:"versions"`
+}
+
+// Get 获取软件商店中的软件列表
+func Get(host string, key string) ([]SoftItem, error) {
+	link := host + "/plugin?action=get_soft_list"
+
+	var result struct {
+		List struct {
+			Data []json.RawMessage `json:"data"`
+		} `json:"list"`
+	}
+
+	err := utils.PostJSON(link, utils.PatchSign(key, url.Values{
+		"p":     {"1"},
+		"type":  {"0"},
+		"row":   {"1000"},
+		"query": {""},
+	}), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	// 各插件返回的字段类型不尽相同，逐个解析并忽略类型不符的字段
+	items := []SoftItem{}
+	for _, raw := range result.List.Data {
+		var item SoftItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			if _, ok := err.(*json.UnmarshalTypeError); !ok {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
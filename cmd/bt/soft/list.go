@@ -0,0 +1,81 @@
+package soft
+
+import (
+	"fmt"
+	"jarvis/cmd/bt/utils"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// 默认只展示运行环境相关的软件
+var runtimes = []string{"nginx", "apache", "openlitespeed", "mysql", "mariadb", "pgsql", "php-", "redis", "memcached", "pure-ftpd"}
+
+var list = &cobra.Command{
+	Use:   "list",
+	Short: "展示已安装及可安装的软件",
+	Long:  color.Success.Render("\r\n展示nginx、MySQL、PHP、Redis等软件的已安装版本、可安装版本及运行状态"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		key, _ := cmd.Flags().GetString("key")
+		all, _ := cmd.Flags().GetBool("all")
+		installed, _ := cmd.Flags().GetBool("installed")
+
+		items, err := Get(host, key)
+		if err != nil {
+			return err
+		}
+
+		color.Yellow.Printf("%-20s %-10s %-16s %s\n", "软件", "状态", "已安装版本", "可安装版本")
+		for _, item := range items {
+			if !all && !isRuntime(item.Name) {
+				continue
+			}
+			if installed && !item.Setup {
+				continue
+			}
+
+			versions := []string{}
+			for _, v := range item.Versions {
+				versions = append(versions, strings.TrimSuffix(v.MVersion+"."+v.Version, "."))
+			}
+
+			status := "未安装"
+			switch {
+			case item.Setup && item.Status:
+				status = "运行中"
+			case item.Setup:
+				status = "已停止"
+			}
+
+			line := fmt.Sprintf("%s %s %s %s", utils.StrPadRight(item.Name, 20, " "), utils.StrPadRight(status, 10, " "),
+				utils.StrPadRight(item.Version, 16, " "), strings.Join(versions, ", "))
+			switch status {
+			case "运行中":
+				color.Infoln(line)
+			case "已停止":
+				color.Warnln(line)
+			default:
+				fmt.Println(line)
+			}
+		}
+
+		return nil
+	},
+}
+
+func isRuntime(name string) bool {
+	for _, prefix := range runtimes {
+		if name == prefix || (strings.HasSuffix(prefix, "-") && strings.HasPrefix(name, prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	list.Flags().Bool("all", false, color.Blue.Render("展示软件商店中的全部软件"))
+	list.Flags().Bool("installed", false, color.Blue.Render("只展示已安装的软件"))
+}
@@ -0,0 +1,16 @@
+package soft
+
+import (
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var SoftCmd = &cobra.Command{
+	Use:   "soft",
+	Short: color.Blue.Render("软件相关操作"),
+	Long:  color.Success.Render("\r\n软件相关操作"),
+}
+
+func init() {
+	SoftCmd.AddCommand(list)
+}
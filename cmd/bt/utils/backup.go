@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+)
+
+// 宝塔备份表中的备份类型
+const (
+	BackupSite     = 0
+	BackupDatabase = 1
+)
+
+type BackupItem struct {
+	Id       int    `json:"id"`
+	Type     int    `json:"type"`
+	Name     string `json:"name"`
+	Pid      int    `json:"pid"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	AddTime  string `json:"addtime"`
+}
+
+// Time 解析备份时间，失败时返回零值
+func (b BackupItem) Time() time.Time {
+	t, _ := time.ParseInLocation("2006-01-02 15:04:05", b.AddTime, time.Local)
+	return t
+}
+
+func backupModule(typ int) string {
+	if typ == BackupSite {
+		return "site"
+	}
+	return "database"
+}
+
+// ListBackups 获取网站或数据库的备份列表，按时间从新到旧排序
+func ListBackups(host string, key string, typ int, id int) ([]BackupItem, error) {
+	link := host + "/data?action=getData&table=backup"
+
+	var result struct {
+		Data []BackupItem `json:"data"`
+	}
+
+	err := PostJSON(link, PatchSign(key, url.Values{
+		"search": {fmt.Sprint(id)},
+		"type":   {fmt.Sprint(typ)},
+		"limit":  {"1000"},
+		"p":      {"1"},
+	}), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []BackupItem{}
+	for _, item := range result.Data {
+		if item.Pid == id && item.Type == typ {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Time().After(items[j].Time())
+	})
+
+	return items, nil
+}
+
+// RunBackup 触发备份并轮询备份列表，直到出现新的备份记录
+func RunBackup(host string, key string, typ int, id int, wait time.Duration) (BackupItem, error) {
+	before, err := ListBackups(host, key, typ, id)
+	if err != nil {
+		return BackupItem{}, err
+	}
+	existing := map[int]bool{}
+	for _, item := range before {
+		existing[item.Id] = true
+	}
+
+	link := host + "/" + backupModule(typ) + "?action=ToBackup"
+	err = PostJSON(link, PatchSign(key, url.Values{
+		"id": {fmt.Sprint(id)},
+	}), nil)
+	// 大网站备份耗时较长，请求超时不代表失败，继续等待备份记录出现
+	if err != nil && !isTimeout(err) {
+		return BackupItem{}, err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		items, err := ListBackups(host, key, typ, id)
+		if err != nil {
+			return BackupItem{}, err
+		}
+		for _, item := range items {
+			if !existing[item.Id] {
+				return item, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return BackupItem{}, errors.New("等待备份完成超时")
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// DeleteBackup 删除备份记录及文件
+func DeleteBackup(host string, key string, item BackupItem) error {
+	link := host + "/" + backupModule(item.Type) + "?action=DelBackup"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"id": {fmt.Sprint(item.Id)},
+	}), nil)
+}
+
+func isTimeout(err error) bool {
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// VerifyGzip 完整读取gzip文件，由gzip自带的CRC32及长度校验内容是否完整
+func VerifyGzip(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
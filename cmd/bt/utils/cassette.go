@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 签名字段每次请求都不同，录制时脱敏，匹配时忽略
+var signFields = []string{"request_time", "request_token"}
+
+// interaction 录制下来的一次请求及响应
+type interaction struct {
+	Key      string              `json:"key"`
+	URL      string              `json:"url"`
+	Form     map[string][]string `json:"form"`
+	Files    map[string]string   `json:"files,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Response string              `json:"response"`
+	Base64   bool                `json:"base64,omitempty"`
+}
+
+// cassette 录制或回放的状态，mode为空时直接访问网络
+var cassette struct {
+	mode   string
+	dir    string
+	seq    int
+	replay map[string][]interaction
+}
+
+// Record 将之后的每次请求及响应保存到dir目录
+func Record(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	cassette.mode = "record"
+	cassette.dir = dir
+	cassette.seq = len(files)
+
+	return nil
+}
+
+// Replay 之后的请求不访问网络，而是返回dir目录中录制的响应
+func Replay(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("目录中没有录制的请求：" + dir)
+	}
+	sort.Strings(files)
+
+	cassette.mode = "replay"
+	cassette.dir = dir
+	cassette.replay = map[string][]interaction{}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var item interaction
+		if err := json.Unmarshal(content, &item); err != nil {
+			return fmt.Errorf("无法解析录制文件 %s：%s", file, err)
+		}
+		cassette.replay[item.Key] = append(cassette.replay[item.Key], item)
+	}
+
+	return nil
+}
+
+// exchange 根据录制或回放状态发送请求，do负责实际的网络请求
+func exchange(link string, data url.Values, files map[string]string, do func() (string, error)) (string, error) {
+	key := requestKey(link, data, files)
+
+	switch cassette.mode {
+	case "replay":
+		queue := cassette.replay[key]
+		if len(queue) == 0 {
+			return "", errors.New("没有录制过该请求：" + link)
+		}
+		// 相同的请求按录制的顺序依次返回，最后一个响应重复使用
+		item := queue[0]
+		if len(queue) > 1 {
+			cassette.replay[key] = queue[1:]
+		}
+		return item.decode()
+	case "record":
+		result, err := do()
+		if saveErr := saveInteraction(key, link, data, files, result, err); saveErr != nil {
+			return result, saveErr
+		}
+		return result, err
+	}
+
+	return do()
+}
+
+func (i interaction) decode() (string, error) {
+	if i.Error != "" {
+		return "", errors.New(i.Error)
+	}
+	if i.Base64 {
+		content, err := base64.StdEncoding.DecodeString(i.Response)
+		return string(content), err
+	}
+
+	return i.Response, nil
+}
+
+// saveInteraction 保存一次请求，文件名为序号加action，便于在问题报告中查看
+func saveInteraction(key string, link string, data url.Values, files map[string]string, result string, err error) error {
+	form := map[string][]string{}
+	for name, values := range data {
+		form[name] = values
+	}
+	for _, field := range signFields {
+		if _, ok := form[field]; ok {
+			form[field] = []string{"REDACTED"}
+		}
+	}
+
+	item := interaction{Key: key, URL: link, Form: form, Response: result}
+	if len(files) > 0 {
+		item.Files = map[string]string{}
+		for field, path := range files {
+			item.Files[field] = filepath.Base(path)
+		}
+	}
+	if err != nil {
+		item.Error = err.Error()
+	}
+	if !utf8.ValidString(result) {
+		item.Response = base64.StdEncoding.EncodeToString([]byte(result))
+		item.Base64 = true
+	}
+
+	content, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cassette.seq++
+	name := fmt.Sprintf("%04d-%s.json", cassette.seq, actionName(link))
+
+	return os.WriteFile(filepath.Join(cassette.dir, name), content, 0644)
+}
+
+// requestKey 由路径、查询参数及表单生成请求的标识，忽略宝塔地址及签名字段
+func requestKey(link string, data url.Values, files map[string]string) string {
+	var b strings.Builder
+
+	if parsed, err := url.Parse(link); err == nil {
+		b.WriteString(parsed.Path + "?" + parsed.Query().Encode())
+	} else {
+		b.WriteString(link)
+	}
+
+	form := url.Values{}
+	for name, values := range data {
+		form[name] = values
+	}
+	for _, field := range signFields {
+		form.Del(field)
+	}
+	b.WriteString("\n" + form.Encode())
+
+	fields := make([]string, 0, len(files))
+	for field, path := range files {
+		fields = append(fields, field+"="+filepath.Base(path))
+	}
+	sort.Strings(fields)
+	b.WriteString("\n" + strings.Join(fields, "&"))
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(b.String())))[:16]
+}
+
+func actionName(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "request"
+	}
+
+	name := parsed.Query().Get("action")
+	if name == "" {
+		name = strings.Trim(strings.Replace(parsed.Path, "/", "_", -1), "_")
+	}
+	if name == "" {
+		return "request"
+	}
+
+	return name
+}
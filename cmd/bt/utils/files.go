@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadFile 通过宝塔文件接口读取文件内容
+func ReadFile(host string, key string, path string) (string, error) {
+	link := host + "/files?action=GetFileBody"
+
+	var result struct {
+		Data string `json:"data"`
+	}
+
+	err := PostJSON(link, PatchSign(key, url.Values{
+		"path": {path},
+	}), &result)
+
+	return result.Data, err
+}
+
+// SaveFile 通过宝塔文件接口保存文件内容
+func SaveFile(host string, key string, path string, content string) error {
+	link := host + "/files?action=SaveFileBody"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"path":     {path},
+		"data":     {content},
+		"encoding": {"utf-8"},
+	}), nil)
+}
+
+// CopyFile 通过宝塔文件接口复制文件或目录，目标已存在时宝塔会拒绝复制目录
+func CopyFile(host string, key string, src string, dst string) error {
+	link := host + "/files?action=CopyFile"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"sfile": {src},
+		"dfile": {dst},
+	}), nil)
+}
+
+// CreateFile 通过宝塔文件接口创建空文件，SaveFile只能写入已存在的文件
+func CreateFile(host string, key string, path string) error {
+	link := host + "/files?action=CreateFile"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"path": {path},
+	}), nil)
+}
+
+// FileEntry 目录中的文件名及大小
+type FileEntry struct {
+	Name string
+	Size int64
+}
+
+// ListDir 通过宝塔文件接口列出目录下的文件和子目录名称
+func ListDir(host string, key string, path string) (files []string, dirs []string, err error) {
+	fileEntries, dirEntries, err := listDir(host, key, path, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entryNames(fileEntries), entryNames(dirEntries), nil
+}
+
+// ListFiles 通过宝塔文件接口列出目录下的文件及大小
+func ListFiles(host string, key string, path string) ([]FileEntry, error) {
+	files, _, err := listDir(host, key, path, "")
+
+	return files, err
+}
+
+// FileSize 通过宝塔文件接口获取文件大小，按文件名搜索目录，文件不存在时返回-1
+func FileSize(host string, key string, file string) (int64, error) {
+	files, _, err := listDir(host, key, path.Dir(file), path.Base(file))
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range files {
+		if entry.Name == path.Base(file) {
+			return entry.Size, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// listDir 逐页读取目录，search 不为空时只列出名称包含该关键字的文件和子目录
+func listDir(host string, key string, path string, search string) (files []FileEntry, dirs []FileEntry, err error) {
+	const pageSize = 1000
+	link := host + "/files?action=GetDir"
+	previous := ""
+
+	for page := 1; ; page++ {
+		var result struct {
+			Dir   []json.RawMessage `json:"DIR"`
+			Files []json.RawMessage `json:"FILES"`
+		}
+
+		err = PostJSON(link, PatchSign(key, url.Values{
+			"path":    {path},
+			"search":  {search},
+			"showRow": {strconv.Itoa(pageSize)},
+			"p":       {strconv.Itoa(page)},
+		}), &result)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// 目录和文件合在一起分页，不足一页说明已是最后一页
+		entries := append(append([]json.RawMessage{}, result.Dir...), result.Files...)
+		if len(entries) == 0 {
+			return files, dirs, nil
+		}
+		// 不支持分页的宝塔每次返回相同的内容
+		if page > 1 && string(entries[0]) == previous {
+			return files, dirs, nil
+		}
+		previous = string(entries[0])
+
+		files = append(files, parseEntries(result.Files)...)
+		dirs = append(dirs, parseEntries(result.Dir)...)
+		if len(entries) < pageSize {
+			return files, dirs, nil
+		}
+	}
+}
+
+func entryNames(entries []FileEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+
+	return names
+}
+
+// parseEntries 解析GetDir返回的条目，旧版本为 "名称;大小;时间;..." 字符串，新版本为对象
+func parseEntries(entries []json.RawMessage) []FileEntry {
+	parsed := []FileEntry{}
+	for _, entry := range entries {
+		var line string
+		if json.Unmarshal(entry, &line) == nil {
+			fields := strings.Split(line, ";")
+			item := FileEntry{Name: fields[0]}
+			if len(fields) > 1 {
+				item.Size, _ = strconv.ParseInt(fields[1], 10, 64)
+			}
+			parsed = append(parsed, item)
+			continue
+		}
+
+		var object struct {
+			Name string          `json:"name"`
+			Nm   string          `json:"nm"`
+			Size json.RawMessage `json:"size"`
+			Sz   json.RawMessage `json:"sz"`
+		}
+		if json.Unmarshal(entry, &object) == nil {
+			item := FileEntry{Name: object.Name}
+			if item.Name == "" {
+				item.Name = object.Nm
+			}
+			// 大小可能是数字或字符串
+			size := object.Size
+			if len(size) == 0 {
+				size = object.Sz
+			}
+			item.Size, _ = strconv.ParseInt(strings.Trim(string(size), `"`), 10, 64)
+			if item.Name != "" {
+				parsed = append(parsed, item)
+			}
+		}
+	}
+
+	return parsed
+}
+
+// Zip 通过宝塔文件接口将dir目录下的name打包为tar.gz
+func Zip(host string, key string, dir string, name string, archive string) error {
+	link := host + "/files?action=Zip"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"path":   {dir},
+		"sfile":  {name},
+		"dfile":  {archive},
+		"z_type": {"tar.gz"},
+	}), nil)
+}
+
+// UnZip 通过宝塔文件接口将tar.gz解压到dir目录
+func UnZip(host string, key string, archive string, dir string) error {
+	link := host + "/files?action=UnZip"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"sfile":    {archive},
+		"dfile":    {dir},
+		"type":     {"tar"},
+		"coding":   {"UTF-8"},
+		"password": {""},
+	}), nil)
+}
+
+// DeleteFile 通过宝塔文件接口删除文件
+func DeleteFile(host string, key string, path string) error {
+	link := host + "/files?action=DeleteFile"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"path": {path},
+	}), nil)
+}
+
+// DeleteDir 通过宝塔文件接口删除目录
+func DeleteDir(host string, key string, path string) error {
+	link := host + "/files?action=DeleteDir"
+
+	return PostJSON(link, PatchSign(key, url.Values{
+		"path": {path},
+	}), nil)
+}
+
+// UploadFile 通过宝塔文件接口将本地文件上传到dir目录
+func UploadFile(host string, key string, local string, dir string) error {
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+
+	link := host + "/files?action=upload"
+	result, err := Upload(link, PatchSign(key, url.Values{
+		"f_path":  {dir},
+		"f_name":  {filepath.Base(local)},
+		"f_size":  {fmt.Sprint(info.Size())},
+		"f_start": {"0"},
+	}), map[string]string{"blob": local})
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		Status bool   `json:"status"`
+		Msg    string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(result), &status) != nil || !status.Status {
+		return fmt.Errorf("上传失败：%s", result)
+	}
+
+	return nil
+}
@@ -1,11 +1,19 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,14 +28,212 @@ func PatchSign(key string, data url.Values) url.Values {
 }
 
 func Post(url string, data url.Values) string {
-	// 超时时间：5秒
-	client := &http.Client{Timeout: 20 * time.Second}
-	response, err := client.Post(url, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	result, err := PostE(url, data)
 	if err != nil {
 		panic(err)
 	}
+
+	return result
+}
+
+// PostE 以表单方式发送请求，出错时返回错误而不是panic
+func PostE(url string, data url.Values) (string, error) {
+	return exchange(url, data, nil, func() (string, error) {
+		// 超时时间：20秒
+		return send(url, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()), 20*time.Second)
+	})
+}
+
+// PostMultipart 以multipart方式发送请求，files为字段名到本地文件路径的映射
+func PostMultipart(url string, data url.Values, files map[string]string) (string, error) {
+	return exchange(url, data, files, func() (string, error) {
+		return sendMultipart(url, data, files, 20*time.Second)
+	})
+}
+
+// Upload 以multipart方式上传文件，不设置超时以便上传大文件
+func Upload(url string, data url.Values, files map[string]string) (string, error) {
+	return exchange(url, data, files, func() (string, error) {
+		return sendMultipart(url, data, files, 0)
+	})
+}
+
+// sendMultipart 边读取文件边发送，避免将大文件读入内存
+func sendMultipart(url string, data url.Values, files map[string]string, timeout time.Duration) (string, error) {
+	reader, pipe := io.Pipe()
+	writer := multipart.NewWriter(pipe)
+
+	go func() {
+		pipe.CloseWithError(writeMultipart(writer, data, files))
+	}()
+
+	return send(url, writer.FormDataContentType(), reader, timeout)
+}
+
+func writeMultipart(writer *multipart.Writer, data url.Values, files map[string]string) error {
+	for name, values := range data {
+		for _, value := range values {
+			if err := writer.WriteField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for field, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		part, err := writer.CreateFormFile(field, filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// send 发送请求，timeout为0时不限制时间
+func send(url string, contentType string, body io.Reader, timeout time.Duration) (string, error) {
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Post(url, contentType, body)
+	if err != nil {
+		return "", err
+	}
 	defer response.Body.Close()
 
-	result, _ := ioutil.ReadAll(response.Body)
-	return string(result)
+	result, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// PostJSON 发送请求并将JSON响应解析到v，宝塔返回 status=false 时转换为错误
+func PostJSON(url string, data url.Values, v interface{}) error {
+	result, err := PostE(url, data)
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		Status *bool  `json:"status"`
+		Msg    string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(result), &status) == nil && status.Status != nil && !*status.Status {
+		return errors.New(status.Msg)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(result), v); err != nil {
+		return fmt.Errorf("无法解析宝塔响应：%s", result)
+	}
+
+	return nil
+}
+
+// Download 以表单方式发送请求并将响应内容写入w，不设置超时以便下载大文件
+func Download(url string, data url.Values, w io.Writer) (int64, error) {
+	// 录制或回放时需要完整的响应内容，改为读入内存
+	if cassette.mode != "" {
+		result, err := exchange(url, data, nil, func() (string, error) {
+			var out bytes.Buffer
+			_, err := download(url, data, &out)
+			return out.String(), err
+		})
+		if err != nil {
+			return 0, err
+		}
+		n, err := io.WriteString(w, result)
+		return int64(n), err
+	}
+
+	return download(url, data, w)
+}
+
+func download(url string, data url.Values, w io.Writer) (int64, error) {
+	response, err := http.Post(url, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("下载失败：%s", response.Status)
+	}
+
+	return io.Copy(w, response.Body)
+}
+
+// DownloadRange 下载从offset开始的内容并写入w，返回文件的总大小，服务器没有返回总大小时为-1。
+// offset超过文件大小时不写入内容，服务器不支持Range时跳过前offset个字节
+func DownloadRange(url string, data url.Values, offset int64, w io.Writer) (int64, error) {
+	if cassette.mode != "" {
+		var out bytes.Buffer
+		if _, err := Download(url, data, &out); err != nil {
+			return 0, err
+		}
+		total := int64(out.Len())
+		if offset < total {
+			_, err := w.Write(out.Bytes()[offset:])
+			return total, err
+		}
+		return total, nil
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+		_, err := io.Copy(w, response.Body)
+		return rangeTotal(response.Header.Get("Content-Range")), err
+	case http.StatusRequestedRangeNotSatisfiable:
+		return rangeTotal(response.Header.Get("Content-Range")), nil
+	case http.StatusOK:
+		skipped, err := io.CopyN(ioutil.Discard, response.Body, offset)
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		n, err := io.Copy(w, response.Body)
+		return offset + n, err
+	}
+
+	return 0, fmt.Errorf("下载失败：%s", response.Status)
+}
+
+// rangeTotal 解析 Content-Range 中的总大小，如 bytes 100-199/1000、bytes */1000，
+// 没有该响应头或总大小为 * 时返回-1
+func rangeTotal(header string) int64 {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return total
 }
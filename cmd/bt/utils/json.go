@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+// PrettyJSON 格式化并高亮JSON，内容不是JSON时原样返回
+func PrettyJSON(raw string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(raw), "", "  "); err != nil {
+		return raw
+	}
+
+	return highlight(out.String())
+}
+
+// highlight 为已缩进的JSON文本染色：键、字符串、数字、布尔及null分别使用不同颜色
+func highlight(text string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if end > len(text) {
+				end = len(text)
+			}
+			token := text[i:end]
+			if strings.HasPrefix(strings.TrimLeft(text[end:], " "), ":") {
+				b.WriteString(color.Blue.Render(token))
+			} else {
+				b.WriteString(color.Green.Render(token))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(text) && strings.IndexByte("+-.eE0123456789", text[end]) >= 0 {
+				end++
+			}
+			b.WriteString(color.Yellow.Render(text[i:end]))
+			i = end
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "null"):
+			b.WriteString(color.Magenta.Render(text[i : i+4]))
+			i += 4
+		case strings.HasPrefix(text[i:], "false"):
+			b.WriteString(color.Magenta.Render(text[i : i+5]))
+			i += 5
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// Query 按类似jq的路径表达式提取字段，支持 .a.b、.a[0]、.a[] 以及 .["key"]
+func Query(raw string, expr string) ([]interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.New("响应内容不是有效的JSON：" + err.Error())
+	}
+
+	steps, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{value}
+	for _, step := range steps {
+		var next []interface{}
+		for _, current := range results {
+			values, err := step.apply(current)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, values...)
+		}
+		results = next
+	}
+
+	return results, nil
+}
+
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+func (s pathStep) apply(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return []interface{}{nil}, nil
+	}
+
+	switch {
+	case s.iterate:
+		switch v := value.(type) {
+		case []interface{}:
+			return v, nil
+		case map[string]interface{}:
+			values := make([]interface{}, 0, len(v))
+			for _, item := range v {
+				values = append(values, item)
+			}
+			return values, nil
+		}
+		return nil, fmt.Errorf("无法遍历 %s", typeName(value))
+	case s.isIndex:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("无法用下标 %d 访问 %s", s.index, typeName(value))
+		}
+		index := s.index
+		if index < 0 {
+			index += len(list)
+		}
+		if index < 0 || index >= len(list) {
+			return []interface{}{nil}, nil
+		}
+		return []interface{}{list[index]}, nil
+	default:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("无法用键 %q 访问 %s", s.key, typeName(value))
+		}
+		return []interface{}{object[s.key]}, nil
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case []interface{}:
+		return "数组"
+	case map[string]interface{}:
+		return "对象"
+	case string:
+		return "字符串"
+	case json.Number:
+		return "数字"
+	case bool:
+		return "布尔值"
+	}
+	return "null"
+}
+
+func parsePath(expr string) ([]pathStep, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" || expr == "." {
+		return nil, nil
+	}
+	if !strings.HasPrefix(expr, ".") {
+		return nil, fmt.Errorf("路径表达式必须以 . 开头：%s", expr)
+	}
+
+	var steps []pathStep
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			end := i
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+			if end > i {
+				steps = append(steps, pathStep{key: expr[i:end]})
+			}
+			i = end
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("路径表达式缺少 ]：%s", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+
+			switch {
+			case inner == "":
+				steps = append(steps, pathStep{iterate: true})
+			case strings.HasPrefix(inner, "\""):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("无效的键：%s", inner)
+				}
+				steps = append(steps, pathStep{key: key})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("无效的下标：%s", inner)
+				}
+				steps = append(steps, pathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("无法解析路径表达式：%s", expr)
+		}
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// StatePath 返回本地状态文件的路径，位于 ~/.jarvis/bt 目录下
+func StatePath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".jarvis", "bt", name), nil
+}
+
+// LoadState 读取本地状态文件，文件不存在时保持v不变
+func LoadState(name string, v interface{}) error {
+	path, err := StatePath(name)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, v)
+}
+
+// SaveState 将v写入本地状态文件
+func SaveState(name string, v interface{}) error {
+	path, err := StatePath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0600)
+}
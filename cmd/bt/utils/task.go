@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PendingTasks 宝塔后台任务队列中等待及执行中的任务，较新的宝塔在后台执行压缩、解压。
+// 没有任务队列的旧版宝塔同步执行这些操作，返回空列表
+func PendingTasks(host string, key string) ([]map[string]interface{}, error) {
+	result, err := PostE(host+"/task?action=get_task_lists", PatchSign(key, url.Values{
+		"status": {"-3"},
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []map[string]interface{}
+	if json.Unmarshal([]byte(result), &tasks) == nil {
+		return tasks, nil
+	}
+
+	var status struct {
+		Status *bool  `json:"status"`
+		Msg    string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(result), &status) == nil && status.Status != nil && !*status.Status {
+		return nil, errors.New(status.Msg)
+	}
+
+	return nil, nil
+}
+
+// WaitTasks 等待任务队列中与 target（如压缩包路径）相关的任务全部完成
+func WaitTasks(host string, key string, target string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		tasks, err := PendingTasks(host, key)
+		if err != nil {
+			return err
+		}
+
+		pending := false
+		for _, task := range tasks {
+			if strings.Contains(fmt.Sprint(task), target) {
+				pending = true
+			}
+		}
+		if !pending {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("等待宝塔后台任务超时：" + target)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+// 包含密码的语句不写入历史记录
+var secretPattern = regexp.MustCompile(`(?i)identified\s+(with\s+\S+\s+)?by|password\s*\(|\bpassword\s*=`)
+
+var console = &cobra.Command{
+	Use:   "console",
+	Short: "交互式SQL终端",
+	Long: color.Success.Render("\r\n交互式SQL终端，语句以 ; 结尾，支持多行输入及历史记录。\r\n" +
+		"  \\format table|csv|json|markdown  切换输出格式\r\n" +
+		"  \\export <file>                   导出上一次的查询结果，按扩展名选择格式\r\n" +
+		"  \\q                               退出"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("db")
+		format, _ := cmd.Flags().GetString("format")
+		readonly, _ := cmd.Flags().GetBool("readonly")
+
+		ctx := context.Background()
+		conn, closeAll, err := session(cmd, ctx, name)
+		if err != nil {
+			return err
+		}
+		defer closeAll()
+
+		if readonly {
+			// 服务器端同样限制为只读，避免关键字判断遗漏
+			if _, err := conn.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY"); err != nil {
+				return fmt.Errorf("开启只读模式失败：%s", err)
+			}
+			color.Warnln("只读模式：将拒绝执行写入语句")
+		}
+
+		line := liner.NewLiner()
+		defer line.Close()
+		line.SetCtrlCAborts(true)
+
+		history := historyPath()
+		if file, err := os.Open(history); err == nil {
+			line.ReadHistory(file)
+			file.Close()
+		}
+		defer func() {
+			if err := os.MkdirAll(filepath.Dir(history), 0700); err != nil {
+				return
+			}
+			if file, err := os.OpenFile(history, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600); err == nil {
+				line.WriteHistory(file)
+				file.Close()
+			}
+		}()
+
+		var last *resultSet
+		var buffer []string
+		for {
+			prompt := "mysql> "
+			if len(buffer) > 0 {
+				prompt = "    -> "
+			}
+
+			input, err := line.Prompt(prompt)
+			if err == liner.ErrPromptAborted {
+				buffer = nil
+				continue
+			}
+			if err == io.EOF {
+				fmt.Println()
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			trimmed := strings.TrimSpace(input)
+			if len(buffer) == 0 && strings.HasPrefix(trimmed, `\`) {
+				fields := strings.Fields(trimmed)
+				switch fields[0] {
+				case `\q`, `\quit`:
+					return nil
+				case `\format`, `\f`:
+					if len(fields) != 2 {
+						color.Warnln(`用法：\format table|csv|json|markdown`)
+						continue
+					}
+					format = fields[1]
+					color.Infoln("输出格式：" + format)
+				case `\export`:
+					if err := exportResult(last, fields[1:]); err != nil {
+						color.Errorln(err.Error())
+					}
+				default:
+					color.Warnln("未知命令：" + fields[0])
+				}
+				continue
+			}
+			if len(buffer) == 0 && (trimmed == "exit" || trimmed == "quit") {
+				return nil
+			}
+			if trimmed == "" && len(buffer) == 0 {
+				continue
+			}
+
+			buffer = append(buffer, input)
+			if !strings.HasSuffix(trimmed, ";") {
+				continue
+			}
+
+			statement := strings.TrimSuffix(strings.TrimSpace(strings.Join(buffer, "\n")), ";")
+			buffer = nil
+			if !secretPattern.MatchString(statement) {
+				line.AppendHistory(statement + ";")
+			}
+
+			if readonly && !isReadStatement(statement) && firstKeyword(statement) != "USE" {
+				color.Errorln("只读模式下不能执行：" + firstKeyword(statement))
+				continue
+			}
+
+			start := time.Now()
+			result, affected, err := execute(ctx, conn, statement)
+			if err != nil {
+				color.Errorln(err.Error())
+				continue
+			}
+			if result == nil {
+				color.Infoln(fmt.Sprintf("影响 %d 行（%s）", affected, time.Since(start).Round(time.Millisecond)))
+				continue
+			}
+
+			last = result
+			if err := result.write(os.Stdout, format); err != nil {
+				color.Errorln(err.Error())
+				continue
+			}
+			color.Gray.Println(fmt.Sprintf("（%s）", time.Since(start).Round(time.Millisecond)))
+		}
+	},
+}
+
+// exportResult 将上一次的查询结果保存到文件
+func exportResult(result *resultSet, args []string) error {
+	if len(args) != 1 {
+		return errors.New(`用法：\export <file>`)
+	}
+	if result == nil {
+		return errors.New("还没有查询结果")
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := result.write(file, formatFor(args[0])); err != nil {
+		return err
+	}
+	color.Infoln(fmt.Sprintf("已将 %d 行保存到：%s", len(result.Rows), args[0]))
+
+	return nil
+}
+
+// historyPath 历史记录保存在 ~/.jarvis/database/history
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".jarvis_history"
+	}
+
+	return filepath.Join(home, ".jarvis", "database", "history")
+}
+
+func init() {
+	console.Flags().String("db", "", "默认数据库")
+	console.Flags().StringP("format", "f", "table", "输出格式：table、csv、json、markdown")
+	console.Flags().Bool("readonly", false, "只允许执行查询语句")
+}
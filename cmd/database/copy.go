@@ -0,0 +1,552 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var copyData = &cobra.Command{
+	Use:   "copy",
+	Short: "脱敏复制数据库",
+	Long: color.Success.Render("\r\n将一个库的数据逐表复制到另一台服务器，按规则文件对列脱敏，可以限制每个表复制的行数。\r\n" +
+		"按外键从被引用的表开始复制，只保留引用的行也被复制了的行，抽样后的数据仍然满足外键约束"),
+	// 使用 --from 及 --to 指定的连接
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		name, _ := cmd.Flags().GetString("db")
+		toName, _ := cmd.Flags().GetString("to-db")
+		rulesFile, _ := cmd.Flags().GetString("rules")
+		limit, _ := cmd.Flags().GetInt("limit")
+		batch, _ := cmd.Flags().GetInt("batch")
+		truncate, _ := cmd.Flags().GetBool("truncate")
+
+		if batch <= 0 {
+			batch = 500
+		}
+
+		rules, err := loadMaskRules(rulesFile)
+		if err != nil {
+			return err
+		}
+		if salt, _ := cmd.Flags().GetString("salt"); salt != "" {
+			rules.Salt = salt
+		}
+		if rules.Salt == "" {
+			random := make([]byte, 16)
+			if _, err := rand.Read(random); err != nil {
+				return err
+			}
+			rules.Salt = hex.EncodeToString(random)
+			color.Warnln("没有指定盐，使用随机值，每次复制的脱敏结果不同")
+		}
+
+		source, err := endpointConfig(cmd, from)
+		if err != nil {
+			return err
+		}
+		target, err := endpointConfig(cmd, to)
+		if err != nil {
+			return err
+		}
+
+		// 没有指定库时使用地址中的库
+		if name == "" {
+			name = source.Database
+		}
+		if toName == "" {
+			toName = target.Database
+		}
+		if toName == "" {
+			toName = name
+		}
+		if name == "" {
+			return errors.New("请通过 --db 或 --from 的地址指定要复制的库")
+		}
+		if err := validateIdent(toName); err != nil {
+			return err
+		}
+		if source.Address() == target.Address() && name == toName {
+			return errors.New("源库与目标库相同")
+		}
+		source.Database, target.Database = name, ""
+
+		// 不解析时间，零值日期等按原样复制
+		raw := func(c *mysql.Config) { c.ParseTime = false }
+		src, err := openWith(source, raw)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := openWith(target, raw)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+
+		schema, err := loadSchema(src, name)
+		if err != nil {
+			return err
+		}
+		warnings, err := rules.validate(schema)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			color.Warnln(warning)
+		}
+
+		c := &copier{
+			ctx:      context.Background(),
+			schema:   schema,
+			rules:    rules,
+			masker:   masker{salt: rules.Salt},
+			limit:    limit,
+			batch:    batch,
+			keys:     map[string]map[string]bool{},
+			complete: map[string]bool{},
+			copied:   map[string]bool{},
+		}
+		if c.src, err = snapshot(c.ctx, src); err != nil {
+			return err
+		}
+		defer c.src.Close()
+		if c.dst, err = c.prepareTarget(dst, toName, truncate); err != nil {
+			return err
+		}
+		defer c.dst.Close()
+
+		order, cyclic := copyOrder(schema)
+		if len(cyclic) > 0 {
+			color.Warnln("以下表之间存在循环外键，先复制的表不检查对后复制的表的引用：" + strings.Join(cyclic, "、"))
+		}
+
+		for _, table := range order {
+			copied, skipped, err := c.copyTable(schema.Tables[table])
+			if err != nil {
+				return fmt.Errorf("复制 %s 失败：%s", table, err)
+			}
+			line := fmt.Sprintf("%s：%d 行", table, copied)
+			if skipped > 0 {
+				line += fmt.Sprintf("，跳过 %d 行引用了未复制的行", skipped)
+			}
+			color.Infoln(line)
+		}
+
+		if err := c.addForeignKeys(); err != nil {
+			return err
+		}
+		color.Success.Println("复制完成：" + target.String() + toName)
+
+		return nil
+	},
+}
+
+// copier 逐表复制并脱敏，keys 记录被外键引用的列中已复制的值
+type copier struct {
+	ctx    context.Context
+	src    *sql.Conn
+	dst    *sql.Conn
+	schema *Schema
+	rules  *maskRules
+	masker masker
+	limit  int
+	batch  int
+	// keys 表名及被引用的列 -> 已复制的值
+	keys map[string]map[string]bool
+	// complete 完整复制、不需要检查引用的表
+	complete map[string]bool
+	// copied 已处理的表
+	copied map[string]bool
+	// created 在目标库中新建的表，复制完成后再添加外键
+	created []string
+}
+
+// snapshot 在一致性快照中读取源库
+func snapshot(ctx context.Context, db *sql.DB) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{
+		"SET SESSION time_zone = '+00:00'",
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("开启快照事务失败：%s", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// prepareTarget 创建目标库及缺少的表，truncate 时清空已有的表
+func (c *copier) prepareTarget(db *sql.DB, name string, truncate bool) (*sql.Conn, error) {
+	query := "CREATE DATABASE IF NOT EXISTS " + quoteIdent(name)
+	if c.schema.Collation != "" {
+		query += " CHARACTER SET " + c.schema.Charset + " COLLATE " + c.schema.Collation
+	}
+	if _, err := db.Exec(query); err != nil {
+		return nil, fmt.Errorf("创建目标库失败：%s", err)
+	}
+	existing, err := loadSchema(db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, query := range []string{
+		"USE " + quoteIdent(name),
+		"SET SESSION time_zone = '+00:00'",
+		"SET SESSION FOREIGN_KEY_CHECKS = 0",
+		"SET SESSION UNIQUE_CHECKS = 0",
+		"SET SESSION sql_mode = 'NO_AUTO_VALUE_ON_ZERO'",
+	} {
+		if _, err := conn.ExecContext(c.ctx, query); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	for _, table := range c.schema.TableNames() {
+		t := c.schema.Tables[table]
+		current := existing.Tables[table]
+		if current == nil {
+			if _, err := conn.ExecContext(c.ctx, createTable(t, diffOptions{IgnoreAutoIncrement: true})); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("创建表 %s 失败：%s", table, err)
+			}
+			c.created = append(c.created, table)
+			continue
+		}
+
+		for _, column := range copyColumns(t) {
+			if current.Column(column) == nil {
+				conn.Close()
+				return nil, fmt.Errorf("目标表 %s 缺少列 %s，可以先使用 database diff 同步表结构", table, column)
+			}
+		}
+		if truncate {
+			if _, err := conn.ExecContext(c.ctx, "TRUNCATE TABLE "+quoteIdent(table)); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("清空表 %s 失败：%s", table, err)
+			}
+		}
+	}
+
+	return conn, nil
+}
+
+// addForeignKeys 为新建的表添加外键，关闭了外键检查，不会校验已复制的数据
+func (c *copier) addForeignKeys() error {
+	for _, table := range c.created {
+		for _, fk := range c.schema.Tables[table].ForeignKeys {
+			if _, err := c.dst.ExecContext(c.ctx, "ALTER TABLE "+quoteIdent(table)+" ADD "+foreignKeyDefinition(fk)); err != nil {
+				return fmt.Errorf("添加外键 %s.%s 失败：%s", table, fk.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyOrder 按外键排序，被引用的表在前，返回存在循环引用的表
+func copyOrder(schema *Schema) ([]string, []string) {
+	depends := map[string]map[string]bool{}
+	for name, t := range schema.Tables {
+		depends[name] = map[string]bool{}
+		for _, fk := range t.ForeignKeys {
+			if fk.RefTable != name && schema.Tables[fk.RefTable] != nil {
+				depends[name][fk.RefTable] = true
+			}
+		}
+	}
+
+	var order []string
+	done := map[string]bool{}
+	for len(done) < len(depends) {
+		var ready []string
+		for name, parents := range depends {
+			if done[name] {
+				continue
+			}
+			blocked := false
+			for parent := range parents {
+				if !done[parent] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			done[name] = true
+		}
+		order = append(order, ready...)
+	}
+
+	var cyclic []string
+	for _, name := range schema.TableNames() {
+		if !done[name] {
+			cyclic = append(cyclic, name)
+		}
+	}
+
+	return append(order, cyclic...), cyclic
+}
+
+// copyColumns 可以插入的列，不含生成列
+func copyColumns(t *Table) []string {
+	var columns []string
+	for _, column := range t.Columns {
+		if column.Generation == "" {
+			columns = append(columns, column.Name)
+		}
+	}
+
+	return columns
+}
+
+// referenced 其他表的外键引用的本表的列
+func (c *copier) referenced(table string) [][]string {
+	var sets [][]string
+	seen := map[string]bool{}
+	for _, t := range c.schema.Tables {
+		for _, fk := range t.ForeignKeys {
+			key := strings.Join(fk.RefColumns, ",")
+			if fk.RefTable == table && !seen[key] {
+				seen[key] = true
+				sets = append(sets, fk.RefColumns)
+			}
+		}
+	}
+
+	return sets
+}
+
+// checks 需要检查引用的外键：被引用的表已处理且没有完整复制，self 为 true 时包括引用本表的外键
+func (c *copier) checks(t *Table, self bool) []*ForeignKey {
+	var fks []*ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if fk.RefTable == t.Name && self || fk.RefTable != t.Name && c.copied[fk.RefTable] && !c.complete[fk.RefTable] {
+			fks = append(fks, fk)
+		}
+	}
+
+	return fks
+}
+
+// copyTable 复制一个表，返回复制及因引用的行未复制而跳过的行数
+func (c *copier) copyTable(t *Table) (int, int, error) {
+	rule := c.rules.Tables[t.Name]
+	limit := c.rules.limit(t.Name, c.limit)
+	// 引用的表都完整复制时本表也是完整的，不需要检查引用本表的外键
+	complete := !rule.Skip && rule.Where == "" && limit == 0 && len(c.checks(t, false)) == 0
+	checks := c.checks(t, !complete)
+	c.copied[t.Name] = true
+	c.complete[t.Name] = complete
+	if rule.Skip {
+		return 0, 0, nil
+	}
+
+	columns := copyColumns(t)
+	positions := map[string]int{}
+	quoted := make([]string, len(columns))
+	rules := make([]maskRule, len(columns))
+	definitions := make([]*Column, len(columns))
+	for i, column := range columns {
+		positions[column] = i
+		quoted[i] = quoteIdent(column)
+		rules[i] = c.rules.rule(t.Name, column)
+		definitions[i] = t.Column(column)
+	}
+	list := strings.Join(quoted, ", ")
+
+	// 记录被引用的列中已复制的值，完整复制的表不需要记录
+	var recorded [][]string
+	if !complete {
+		recorded = c.referenced(t.Name)
+	}
+	tuple := func(values []sql.RawBytes, names []string) (string, bool) {
+		parts := make([]string, len(names))
+		for i, name := range names {
+			value := values[positions[name]]
+			if value == nil {
+				return "", false
+			}
+			parts[i] = string(value)
+		}
+		return strings.Join(parts, "\x00"), true
+	}
+
+	query := "SELECT " + list + " FROM " + quoteIdent(t.Name)
+	if rule.Where != "" {
+		query += " WHERE " + rule.Where
+	}
+	if pk := t.PrimaryKey(); len(pk) > 0 {
+		keys := make([]string, len(pk))
+		for i, column := range pk {
+			keys[i] = quoteIdent(strings.SplitN(column, "(", 2)[0])
+		}
+		query += " ORDER BY " + strings.Join(keys, ", ")
+	}
+
+	// 需要检查引用时可能跳过部分行，按页读取直到复制够行数
+	paging := limit > 0 && len(checks) > 0
+	pageSize := limit
+	if paging {
+		pageSize = limit * 4
+		if pageSize < 1000 {
+			pageSize = 1000
+		}
+	}
+
+	w := &batchInserter{ctx: c.ctx, conn: c.dst, prefix: "INSERT INTO " + quoteIdent(t.Name) + " (" + list + ") VALUES\n", batch: c.batch}
+	copied, skipped := 0, 0
+	for offset := 0; ; offset += pageSize {
+		paged := query
+		if pageSize > 0 {
+			paged += " LIMIT " + strconv.Itoa(pageSize) + " OFFSET " + strconv.Itoa(offset)
+		}
+
+		rows, err := c.src.QueryContext(c.ctx, paged)
+		if err != nil {
+			return copied, skipped, err
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			rows.Close()
+			return copied, skipped, err
+		}
+		values := make([]sql.RawBytes, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		read := 0
+		for (limit == 0 || copied < limit) && rows.Next() {
+			read++
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return copied, skipped, err
+			}
+
+			missing := false
+			for _, fk := range checks {
+				if key, ok := tuple(values, fk.Columns); ok && !c.keys[fk.RefTable+"\x00"+strings.Join(fk.RefColumns, ",")][key] {
+					missing = true
+					break
+				}
+			}
+			if missing {
+				skipped++
+				continue
+			}
+
+			for _, names := range recorded {
+				if key, ok := tuple(values, names); ok {
+					set := t.Name + "\x00" + strings.Join(names, ",")
+					if c.keys[set] == nil {
+						c.keys[set] = map[string]bool{}
+					}
+					c.keys[set][key] = true
+				}
+			}
+
+			literals := make([]string, len(values))
+			for i, value := range values {
+				literals[i] = c.masker.literal(rules[i], definitions[i], value, types[i].DatabaseTypeName())
+			}
+			if err := w.add("(" + strings.Join(literals, ",") + ")"); err != nil {
+				rows.Close()
+				return copied, skipped, err
+			}
+			copied++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return copied, skipped, err
+		}
+
+		if !paging || read < pageSize || copied >= limit {
+			break
+		}
+	}
+
+	return copied, skipped, w.flush()
+}
+
+// batchInserter 将多行合并为一条 INSERT 语句执行
+type batchInserter struct {
+	ctx    context.Context
+	conn   *sql.Conn
+	prefix string
+	batch  int
+	b      strings.Builder
+	rows   int
+}
+
+func (w *batchInserter) add(row string) error {
+	if w.rows == 0 {
+		w.b.WriteString(w.prefix)
+	} else {
+		w.b.WriteString(",\n")
+	}
+	w.b.WriteString(row)
+	w.rows++
+
+	if w.rows >= w.batch || w.b.Len() >= maxStatement {
+		return w.flush()
+	}
+
+	return nil
+}
+
+func (w *batchInserter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+
+	_, err := w.conn.ExecContext(w.ctx, w.b.String())
+	w.b.Reset()
+	w.rows = 0
+
+	return err
+}
+
+func init() {
+	copyData.Flags().String("from", "", "源库的地址或连接配置名称")
+	copyData.Flags().String("to", "", "目标服务器的地址或连接配置名称")
+	copyData.Flags().String("db", "", "要复制的库")
+	copyData.Flags().String("to-db", "", "目标库，默认与源库同名")
+	copyData.Flags().StringP("rules", "r", "masking.yaml", "脱敏规则文件")
+	copyData.Flags().Int("limit", 0, "每个表最多复制的行数，0 表示全部，可以在规则文件中按表指定")
+	copyData.Flags().Int("batch", 500, "每条 INSERT 语句的行数")
+	copyData.Flags().Bool("truncate", false, "复制前清空目标库中已有的表")
+	copyData.Flags().String("salt", "", "脱敏使用的盐，覆盖规则文件中的值")
+}
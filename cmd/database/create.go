@@ -1,57 +1,52 @@
 package database
 
 import (
-	"database/sql"
-	_ "github.com/go-sql-driver/mysql"
+	"fmt"
+
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
 )
 
 var create = &cobra.Command{
-	Use:   "create",
+	Use:   "create [name]",
 	Short: "创建数据库",
-	Long:  color.Success.Render("\r\n创建数据库。"),
-	Run: func(cmd *cobra.Command, args []string) {
-		host, _ := cmd.Flags().GetString("host")
-		username, _ := cmd.Flags().GetString("username")
-		password, _ := cmd.Flags().GetString("password")
+	Long:  color.Success.Render("\r\n创建数据库。SQLite 在当前数据库文件所在的目录中创建新的文件"),
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		name, _ := cmd.Flags().GetString("name")
-		if host == "" {
-			host = "127.0.0.1"
-		}
-		if username == "" {
-			username = "root"
-		}
-		if password == "" {
-			password = "root"
+		charset, _ := cmd.Flags().GetString("charset")
+		collation, _ := cmd.Flags().GetString("collation")
+		if len(args) > 0 {
+			name = args[0]
 		}
 
 		if name == "" {
 			color.Warnln("请输入要新建的数据库名称")
-			return
+			return nil
+		}
+		if err := validateIdent(name); err != nil {
+			return err
 		}
 
-		color.Infoln("地址：" + host)
-		color.Infoln("用户：" + username)
-		color.Infoln("密码：" + password)
 		color.Infoln("新建：" + name + "\r\n")
 
-		// 参考 https://github.com/go-sql-driver/mysql#dsn-data-source-name 获取详情
-		dsn := username + ":" + password + "@tcp(" + host + ":3306)/?charset=utf8mb4&parseTime=True&loc=Local"
-		db, err := sql.Open("mysql", dsn)
+		db, cfg, d, err := connectAny(cmd)
 		if err != nil {
-			color.Infoln("连接数据库失败")
-		} else {
-			_, err := db.Exec("CREATE DATABASE IF NOT EXISTS " + name)
-			if err != nil {
-				color.Errorf("Error %s when creating DB\n", err)
-				return
-			}
-			color.Infoln("成功")
+			return err
 		}
+		defer db.Close()
+
+		if err := d.CreateDatabase(db, cfg, name, createOptions{Charset: charset, Collation: collation}); err != nil {
+			return fmt.Errorf("创建数据库失败：%s", err)
+		}
+		color.Infoln("成功")
+
+		return nil
 	},
 }
 
 func init() {
 	create.Flags().String("name", "", "要新建的数据库名称")
+	create.Flags().String("charset", "", "字符集，MySQL 默认为 utf8mb4，PostgreSQL 默认使用模板库的编码")
+	create.Flags().String("collation", "", "排序规则，默认使用字符集的默认排序规则")
 }
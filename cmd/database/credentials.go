@@ -0,0 +1,186 @@
+package database
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"jarvis/cmd/shared"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// current 已解析的连接配置，避免同一次执行中重复询问密码
+var current *Config
+
+// resolveConfig 按优先级合并连接配置：命令行参数 > 连接配置档案 > 环境变量 > ~/.my.cnf，仍没有密码时在终端中询问
+func resolveConfig(cmd *cobra.Command) (Config, error) {
+	if current != nil {
+		return *current, nil
+	}
+
+	cfg, err := configFromFlags(cmd)
+	if err != nil {
+		return cfg, err
+	}
+
+	// SQLite 没有账号，不需要用户名和密码
+	if cfg.Driver == "sqlite" {
+		current = &cfg
+		return cfg, nil
+	}
+
+	if cfg.User == "" {
+		return cfg, errors.New("请通过 -u、MYSQL_USER、~/.my.cnf 或 --profile 指定用户名")
+	}
+
+	if cfg.Password == "" {
+		if cfg.Password, err = askPassword(cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	current = &cfg
+
+	return cfg, nil
+}
+
+// askPassword 在终端中不回显地读取密码
+func askPassword(cfg Config) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("请通过 MYSQL_PWD、~/.my.cnf 或 --profile 提供密码")
+	}
+
+	fmt.Fprintf(os.Stderr, "%s 的密码：", cfg.User+"@"+cfg.Address())
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	return string(password), err
+}
+
+// applyEnv 读取与mysql客户端相同的环境变量
+func applyEnv(cfg *Config) {
+	if user := os.Getenv("MYSQL_USER"); user != "" {
+		cfg.User = user
+	}
+	if password := os.Getenv("MYSQL_PWD"); password != "" {
+		cfg.Password = password
+	}
+}
+
+// applyOptionFile 读取 my.cnf 中 [client] 段的连接参数，文件不存在时忽略
+func applyOptionFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != "client" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.Replace(strings.TrimSpace(parts[0]), "_", "-", -1)
+		value := unquoteOption(strings.TrimSpace(parts[1]))
+
+		switch name {
+		case "user":
+			cfg.User = value
+		case "password":
+			cfg.Password = value
+		case "host":
+			cfg.Host = value
+		case "port":
+			if port, err := strconv.Atoi(value); err == nil {
+				cfg.Port = port
+			}
+		case "socket":
+			cfg.Socket = value
+		case "ssl-ca":
+			cfg.CA = value
+		case "ssl-cert":
+			cfg.Cert = value
+		case "ssl-key":
+			cfg.Key = value
+		}
+	}
+
+	return scanner.Err()
+}
+
+func unquoteOption(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// defaultOptionFile 返回 ~/.my.cnf 的路径
+func defaultOptionFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".my.cnf")
+}
+
+// maskPassword 展示密码时只显示是否已设置
+func maskPassword(password string) string {
+	if password == "" {
+		return "（未设置）"
+	}
+
+	return "******"
+}
+
+// newPassword 为账号设置的新密码：终端中输入两次确认，非终端或直接回车时随机生成
+func newPassword(user string) (string, bool, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "%s 的新密码（直接回车随机生成）：", user)
+		first, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", false, err
+		}
+		if len(first) > 0 {
+			fmt.Fprint(os.Stderr, "再次输入：")
+			second, err := term.ReadPassword(fd)
+			fmt.Fprintln(os.Stderr)
+			if err != nil {
+				return "", false, err
+			}
+			if string(first) != string(second) {
+				return "", false, errors.New("两次输入的密码不一致")
+			}
+			return string(first), false, nil
+		}
+	}
+
+	return shared.RandomString(20), true, nil
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dialect 不同数据库引擎的差异：连接方式、标识符及字符串的引号、库的列表及创建、账号管理及表大小统计
+type dialect interface {
+	// DefaultPort 默认端口，没有网络连接的引擎为0
+	DefaultPort() int
+	Open(c Config) (*sql.DB, error)
+	// QuoteIdent 为库名、表名及账号名加上引擎使用的引号
+	QuoteIdent(name string) string
+	// QuoteString 生成字符串字面量，调用方需要确认服务器的设置与转义方式一致
+	QuoteString(value string) string
+	ListDatabases(db *sql.DB) ([]string, error)
+	CreateDatabase(db *sql.DB, c Config, name string, opts createOptions) error
+	TableStats(db *sql.DB, schema string) ([]tableStat, error)
+	CreateUser(db *sql.DB, name, host, password string) error
+	DropUser(db *sql.DB, name, host string) error
+	SetPassword(db *sql.DB, name, host, password string) error
+	ListUsers(db *sql.DB) ([]string, error)
+}
+
+// createOptions 创建库时的字符集及排序规则，为空时使用引擎的默认值
+type createOptions struct {
+	Charset   string
+	Collation string
+}
+
+// dialects 按地址的 scheme 选择引擎
+var dialects = map[string]dialect{
+	"mysql":    mysqlDialect{},
+	"postgres": postgresDialect{},
+	"sqlite":   sqliteDialect{},
+}
+
+// schemeAliases 地址中可以使用的其他 scheme
+var schemeAliases = map[string]string{
+	"postgresql": "postgres",
+	"sqlite3":    "sqlite",
+}
+
+// driverFor 由地址的 scheme 得到引擎名称
+func driverFor(scheme string) (string, error) {
+	scheme = strings.ToLower(scheme)
+	if alias, ok := schemeAliases[scheme]; ok {
+		scheme = alias
+	}
+	if _, ok := dialects[scheme]; !ok {
+		return "", fmt.Errorf("不支持的数据库类型：%s，可选 %s", scheme, strings.Join(driverNames(), "、"))
+	}
+
+	return scheme, nil
+}
+
+func driverNames() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// dialect 连接配置对应的引擎
+func (c Config) dialect() dialect {
+	if d, ok := dialects[c.Driver]; ok {
+		return d
+	}
+
+	return dialects["mysql"]
+}
+
+// mysqlDialect MySQL 及 MariaDB
+type mysqlDialect struct{}
+
+func (mysqlDialect) DefaultPort() int {
+	return 3306
+}
+
+func (mysqlDialect) Open(c Config) (*sql.DB, error) {
+	return openWith(c, nil)
+}
+
+// QuoteIdent 反引号，内部的反引号写两次
+func (mysqlDialect) QuoteIdent(name string) string {
+	return quoteIdent(name)
+}
+
+// QuoteString 单引号写两次并转义反斜杠，使用前由 checkBackslash 确认没有开启 NO_BACKSLASH_ESCAPES
+func (mysqlDialect) QuoteString(value string) string {
+	return quoteString(value)
+}
+
+func (mysqlDialect) ListDatabases(db *sql.DB) ([]string, error) {
+	var names []string
+	err := eachRow(db, "SHOW DATABASES", nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+
+	return names, err
+}
+
+func (d mysqlDialect) CreateDatabase(db *sql.DB, c Config, name string, opts createOptions) error {
+	// 字符集及排序规则不能使用引号，只允许字母、数字和下划线
+	if err := validateCharset(opts.Charset); err != nil {
+		return err
+	}
+	if err := validateCharset(opts.Collation); err != nil {
+		return err
+	}
+
+	charset := opts.Charset
+	if charset == "" {
+		charset = "utf8mb4"
+	}
+
+	query := "CREATE DATABASE IF NOT EXISTS " + d.QuoteIdent(name) + " CHARACTER SET " + charset
+	if opts.Collation != "" {
+		query += " COLLATE " + opts.Collation
+	}
+	_, err := db.Exec(query)
+
+	return err
+}
+
+func (mysqlDialect) TableStats(db *sql.DB, schema string) ([]tableStat, error) {
+	placeholders := make([]string, 0, len(systemSchemas))
+	params := []interface{}{}
+	for name := range systemSchemas {
+		placeholders = append(placeholders, "?")
+		params = append(params, name)
+	}
+	filter := "TABLE_SCHEMA NOT IN (" + strings.Join(placeholders, ", ") + ")"
+	if schema != "" {
+		filter = "TABLE_SCHEMA = ?"
+		params = []interface{}{schema}
+	}
+
+	primary := map[string]bool{}
+	err := eachRow(db, "SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLE_CONSTRAINTS WHERE CONSTRAINT_TYPE = 'PRIMARY KEY' AND "+filter, params, func(rows *sql.Rows) error {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return err
+		}
+		primary[schema+"."+table] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []tableStat
+	err = eachRow(db, `SELECT TABLE_SCHEMA, TABLE_NAME, IFNULL(ENGINE, ''), IFNULL(TABLE_ROWS, 0), IFNULL(DATA_LENGTH, 0), IFNULL(INDEX_LENGTH, 0), IFNULL(DATA_FREE, 0)
+		FROM information_schema.TABLES WHERE TABLE_TYPE = 'BASE TABLE' AND `+filter, params, func(rows *sql.Rows) error {
+		t := tableStat{}
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Engine, &t.Rows, &t.Data, &t.Index, &t.Free); err != nil {
+			return err
+		}
+		t.PrimaryKey = primary[t.Schema+"."+t.Name]
+		tables = append(tables, t)
+		return nil
+	})
+
+	return tables, err
+}
+
+func (d mysqlDialect) CreateUser(db *sql.DB, name, host, password string) error {
+	if err := checkBackslash(db, name, host, password); err != nil {
+		return err
+	}
+	_, err := db.Exec("CREATE USER " + account(name, host) + " IDENTIFIED BY " + d.QuoteString(password))
+	return err
+}
+
+func (mysqlDialect) DropUser(db *sql.DB, name, host string) error {
+	if err := checkBackslash(db, name, host); err != nil {
+		return err
+	}
+	_, err := db.Exec("DROP USER " + account(name, host))
+	return err
+}
+
+func (d mysqlDialect) SetPassword(db *sql.DB, name, host, password string) error {
+	if err := checkBackslash(db, name, host, password); err != nil {
+		return err
+	}
+	_, err := db.Exec("ALTER USER " + account(name, host) + " IDENTIFIED BY " + d.QuoteString(password))
+	return err
+}
+
+func (mysqlDialect) ListUsers(db *sql.DB) ([]string, error) {
+	var users []string
+	err := eachRow(db, "SELECT User, Host FROM mysql.user ORDER BY User, Host", nil, func(rows *sql.Rows) error {
+		var name, from string
+		if err := rows.Scan(&name, &from); err != nil {
+			return err
+		}
+		users = append(users, name+"@"+from)
+		return nil
+	})
+
+	return users, err
+}
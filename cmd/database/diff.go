@@ -0,0 +1,446 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var diff = &cobra.Command{
+	Use:   "diff",
+	Short: "对比两个库的表结构",
+	Long:  color.Success.Render("\r\n对比两个库的表、列、索引、外键及字符集，生成使 --to 与 --from 一致的 ALTER 语句。\r\n--from、--to 可以是 mysql://user@host:port/db 地址或保存的连接配置名称"),
+	// 两端各自连接，不使用全局的连接参数
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		output, _ := cmd.Flags().GetString("output")
+		opts := diffOptions{}
+		opts.IgnoreAutoIncrement, _ = cmd.Flags().GetBool("ignore-auto-increment")
+		opts.IgnoreComments, _ = cmd.Flags().GetBool("ignore-comments")
+
+		source, err := loadEndpoint(cmd, from)
+		if err != nil {
+			return err
+		}
+		target, err := loadEndpoint(cmd, to)
+		if err != nil {
+			return err
+		}
+		color.Infoln("源：" + from + " → 目标：" + to + "\r\n")
+
+		changes, statements := diffSchemas(source, target, opts)
+		if len(changes) == 0 {
+			color.Success.Println("表结构一致")
+			return nil
+		}
+
+		for _, change := range changes {
+			switch change[0] {
+			case '+':
+				color.Green.Println(change)
+			case '-':
+				color.Red.Println(change)
+			case '~':
+				color.Yellow.Println(change)
+			default:
+				color.Bold.Println(change)
+			}
+		}
+
+		script := "SET FOREIGN_KEY_CHECKS = 0;\n\n" + strings.Join(statements, ";\n\n") + ";\n\nSET FOREIGN_KEY_CHECKS = 1;\n"
+		if output != "" {
+			if err := os.WriteFile(output, []byte(script), 0644); err != nil {
+				return err
+			}
+			color.Infoln("\r\n已将 ALTER 语句保存到：" + output)
+			return nil
+		}
+
+		fmt.Println()
+		color.Blueln("-- 使目标与源一致的语句：")
+		fmt.Print(script)
+
+		return nil
+	},
+}
+
+type diffOptions struct {
+	IgnoreAutoIncrement bool
+	IgnoreComments      bool
+}
+
+// loadEndpoint 按地址或连接配置名称连接并读取表结构
+func loadEndpoint(cmd *cobra.Command, value string) (*Schema, error) {
+	cfg, err := endpointConfig(cmd, value)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Database == "" {
+		return nil, errors.New("请在地址中指定数据库：" + value)
+	}
+
+	db, err := openWith(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return loadSchema(db, cfg.Database)
+}
+
+// endpointConfig 解析数据库地址或连接配置名称，缺少的用户名和密码使用全局参数、环境变量或 ~/.my.cnf
+func endpointConfig(cmd *cobra.Command, value string) (Config, error) {
+	if value == "" {
+		return Config{}, errors.New("请指定数据库地址")
+	}
+
+	base, err := configFromFlags(cmd)
+	if err != nil {
+		return base, err
+	}
+
+	var cfg Config
+	if strings.Contains(value, "://") {
+		cfg, err = parseURL(value, base)
+	} else {
+		var p Profile
+		if p, err = findProfile(value); err == nil {
+			cfg, err = parseURL(p.URL, base)
+			if p.Password != "" {
+				cfg.Password = p.Password
+			}
+		}
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if cfg.User == "" {
+		return cfg, errors.New("请在地址中指定用户名：" + value)
+	}
+	if cfg.Password == "" {
+		if cfg.Password, err = askPassword(cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// diffSchemas 返回可读的差异及使target与source一致的语句
+func diffSchemas(source *Schema, target *Schema, opts diffOptions) ([]string, []string) {
+	var changes, dropFks, statements, addFks, drops []string
+
+	if source.Charset != target.Charset || source.Collation != target.Collation {
+		changes = append(changes, fmt.Sprintf("~ 库字符集：%s/%s → %s/%s", target.Charset, target.Collation, source.Charset, source.Collation))
+		statements = append(statements, fmt.Sprintf("ALTER DATABASE %s CHARACTER SET %s COLLATE %s", quoteIdent(target.Name), source.Charset, source.Collation))
+	}
+
+	for _, name := range source.TableNames() {
+		t := source.Tables[name]
+		old := target.Tables[name]
+		if old == nil {
+			changes = append(changes, "+ 表 "+name)
+			statements = append(statements, createTable(t, opts))
+			for _, fk := range t.ForeignKeys {
+				addFks = append(addFks, "ALTER TABLE "+quoteIdent(name)+" ADD "+foreignKeyDefinition(fk))
+			}
+			continue
+		}
+
+		var tableChanges, clauses []string
+
+		for _, fk := range old.ForeignKeys {
+			if next := findForeignKey(t, fk.Name); next == nil || foreignKeyDefinition(next) != foreignKeyDefinition(fk) {
+				dropFks = append(dropFks, "ALTER TABLE "+quoteIdent(name)+" DROP FOREIGN KEY "+quoteIdent(fk.Name))
+				if next == nil {
+					tableChanges = append(tableChanges, "  - 外键 "+fk.Name)
+				}
+			}
+		}
+
+		for _, index := range old.Indexes {
+			if next := findIndex(t, index.Name); next == nil || indexDefinition(next) != indexDefinition(index) {
+				clauses = append(clauses, dropIndex(index))
+				if next == nil {
+					tableChanges = append(tableChanges, "  - 索引 "+indexDefinition(index))
+				}
+			}
+		}
+
+		for _, column := range old.Columns {
+			if t.Column(column.Name) == nil {
+				tableChanges = append(tableChanges, "  - 列 "+column.Name)
+				clauses = append(clauses, "DROP COLUMN "+quoteIdent(column.Name))
+			}
+		}
+
+		previous := ""
+		for _, column := range t.Columns {
+			definition := columnDefinition(column, t, opts)
+			position := " FIRST"
+			if previous != "" {
+				position = " AFTER " + quoteIdent(previous)
+			}
+			previous = column.Name
+
+			current := old.Column(column.Name)
+			if current == nil {
+				tableChanges = append(tableChanges, "  + 列 "+definition)
+				clauses = append(clauses, "ADD COLUMN "+definition+position)
+				continue
+			}
+			if before := columnDefinition(current, old, opts); before != definition {
+				tableChanges = append(tableChanges, "  ~ 列 "+before+" → "+definition)
+				clauses = append(clauses, "MODIFY COLUMN "+definition)
+			}
+		}
+
+		for _, index := range t.Indexes {
+			current := findIndex(old, index.Name)
+			if current != nil && indexDefinition(current) == indexDefinition(index) {
+				continue
+			}
+			if current == nil {
+				tableChanges = append(tableChanges, "  + 索引 "+indexDefinition(index))
+			} else {
+				tableChanges = append(tableChanges, "  ~ 索引 "+indexDefinition(current)+" → "+indexDefinition(index))
+			}
+			clauses = append(clauses, "ADD "+indexDefinition(index))
+		}
+
+		for _, fk := range t.ForeignKeys {
+			current := findForeignKey(old, fk.Name)
+			if current != nil && foreignKeyDefinition(current) == foreignKeyDefinition(fk) {
+				continue
+			}
+			if current == nil {
+				tableChanges = append(tableChanges, "  + 外键 "+foreignKeyDefinition(fk))
+			} else {
+				tableChanges = append(tableChanges, "  ~ 外键 "+foreignKeyDefinition(current)+" → "+foreignKeyDefinition(fk))
+			}
+			addFks = append(addFks, "ALTER TABLE "+quoteIdent(name)+" ADD "+foreignKeyDefinition(fk))
+		}
+
+		options, optionChanges := tableOptions(t, old, opts)
+		tableChanges = append(tableChanges, optionChanges...)
+		clauses = append(clauses, options...)
+
+		if len(tableChanges) > 0 {
+			changes = append(changes, "表 "+name+"：")
+			changes = append(changes, tableChanges...)
+		}
+		if len(clauses) > 0 {
+			statements = append(statements, "ALTER TABLE "+quoteIdent(name)+"\n  "+strings.Join(clauses, ",\n  "))
+		}
+	}
+
+	for _, name := range target.TableNames() {
+		if source.Tables[name] == nil {
+			changes = append(changes, "- 表 "+name)
+			drops = append(drops, "DROP TABLE "+quoteIdent(name))
+		}
+	}
+
+	// 先删除外键再修改表，新的外键在所有表都就绪后添加
+	all := append(dropFks, statements...)
+	all = append(all, addFks...)
+	all = append(all, drops...)
+
+	return changes, all
+}
+
+// tableOptions 比较存储引擎、排序规则、注释及自增值
+func tableOptions(t *Table, old *Table, opts diffOptions) ([]string, []string) {
+	var clauses, changes []string
+
+	if t.Engine != old.Engine {
+		clauses = append(clauses, "ENGINE = "+t.Engine)
+		changes = append(changes, "  ~ 存储引擎 "+old.Engine+" → "+t.Engine)
+	}
+	if t.Collation != old.Collation {
+		clauses = append(clauses, "DEFAULT CHARACTER SET "+charsetOf(t.Collation)+" COLLATE "+t.Collation)
+		changes = append(changes, "  ~ 排序规则 "+old.Collation+" → "+t.Collation)
+	}
+	if !opts.IgnoreComments && t.Comment != old.Comment {
+		clauses = append(clauses, "COMMENT = "+quoteString(t.Comment))
+		changes = append(changes, fmt.Sprintf("  ~ 注释 %q → %q", old.Comment, t.Comment))
+	}
+	if !opts.IgnoreAutoIncrement && t.AutoIncrement.Valid && t.AutoIncrement != old.AutoIncrement {
+		clauses = append(clauses, "AUTO_INCREMENT = "+strconv.FormatInt(t.AutoIncrement.Int64, 10))
+		changes = append(changes, fmt.Sprintf("  ~ 自增值 %d → %d", old.AutoIncrement.Int64, t.AutoIncrement.Int64))
+	}
+
+	return clauses, changes
+}
+
+// createTable 由读取到的结构生成建表语句
+func createTable(t *Table, opts diffOptions) string {
+	var lines []string
+	for _, column := range t.Columns {
+		lines = append(lines, columnDefinition(column, t, opts))
+	}
+	for _, index := range t.Indexes {
+		lines = append(lines, indexDefinition(index))
+	}
+
+	statement := "CREATE TABLE " + quoteIdent(t.Name) + " (\n  " + strings.Join(lines, ",\n  ") + "\n)"
+	if t.Engine != "" {
+		statement += " ENGINE=" + t.Engine
+	}
+	if t.Collation != "" {
+		statement += " DEFAULT CHARSET=" + charsetOf(t.Collation) + " COLLATE=" + t.Collation
+	}
+	if !opts.IgnoreAutoIncrement && t.AutoIncrement.Valid {
+		statement += " AUTO_INCREMENT=" + strconv.FormatInt(t.AutoIncrement.Int64, 10)
+	}
+	if !opts.IgnoreComments && t.Comment != "" {
+		statement += " COMMENT=" + quoteString(t.Comment)
+	}
+
+	return statement
+}
+
+var expressionDefault = regexp.MustCompile(`(?i)^(current_timestamp|now|localtimestamp|localtime)(\(\d*\))?$|^\(.*\)$`)
+
+// columnDefinition 生成列定义，与表默认排序规则相同时省略字符集，生成列没有默认值
+func columnDefinition(c *Column, t *Table, opts diffOptions) string {
+	parts := []string{quoteIdent(c.Name), c.Type}
+	if c.Collation != "" && c.Collation != t.Collation {
+		parts = append(parts, "CHARACTER SET "+c.Charset+" COLLATE "+c.Collation)
+	}
+	if c.Generation != "" {
+		// EXTRA 为 VIRTUAL GENERATED 或 STORED GENERATED，MariaDB 旧版本为 PERSISTENT GENERATED
+		parts = append(parts, "GENERATED ALWAYS AS ("+c.Generation+") "+strings.Fields(c.Extra + " VIRTUAL")[0])
+		// MariaDB 的生成列不支持 NULL 约束，只在 NOT NULL 时写出
+		if !c.Nullable {
+			parts = append(parts, "NOT NULL")
+		}
+		if !opts.IgnoreComments && c.Comment != "" {
+			parts = append(parts, "COMMENT "+quoteString(c.Comment))
+		}
+		return strings.Join(parts, " ")
+	}
+	if c.Nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+
+	switch {
+	case !c.Default.Valid || strings.EqualFold(c.Default.String, "NULL"):
+		if c.Nullable {
+			parts = append(parts, "DEFAULT NULL")
+		}
+	case expressionDefault.MatchString(c.Default.String):
+		parts = append(parts, "DEFAULT "+c.Default.String)
+	case strings.HasPrefix(c.Default.String, "'"):
+		// MariaDB 返回的默认值已带引号
+		parts = append(parts, "DEFAULT "+c.Default.String)
+	default:
+		parts = append(parts, "DEFAULT "+quoteString(c.Default.String))
+	}
+
+	if c.Extra != "" {
+		parts = append(parts, c.Extra)
+	}
+	if !opts.IgnoreComments && c.Comment != "" {
+		parts = append(parts, "COMMENT "+quoteString(c.Comment))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// indexDefinition 生成索引定义
+func indexDefinition(index *Index) string {
+	columns := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		// 函数索引的表达式原样写出，加上外层括号后为 ((expr))；前缀索引的长度写在反引号外
+		if strings.HasPrefix(column, "(") {
+			columns[i] = column
+		} else if j := strings.LastIndex(column, "("); j > 0 && strings.HasSuffix(column, ")") {
+			columns[i] = quoteIdent(column[:j]) + column[j:]
+		} else {
+			columns[i] = quoteIdent(column)
+		}
+	}
+	list := "(" + strings.Join(columns, ", ") + ")"
+
+	switch {
+	case index.Name == "PRIMARY":
+		return "PRIMARY KEY " + list
+	case index.Type == "FULLTEXT":
+		return "FULLTEXT KEY " + quoteIdent(index.Name) + " " + list
+	case index.Type == "SPATIAL":
+		return "SPATIAL KEY " + quoteIdent(index.Name) + " " + list
+	case index.Unique:
+		return "UNIQUE KEY " + quoteIdent(index.Name) + " " + list
+	}
+
+	return "KEY " + quoteIdent(index.Name) + " " + list
+}
+
+func dropIndex(index *Index) string {
+	if index.Name == "PRIMARY" {
+		return "DROP PRIMARY KEY"
+	}
+
+	return "DROP INDEX " + quoteIdent(index.Name)
+}
+
+// foreignKeyDefinition 生成外键定义
+func foreignKeyDefinition(fk *ForeignKey) string {
+	quote := func(names []string) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = quoteIdent(name)
+		}
+		return strings.Join(quoted, ", ")
+	}
+
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s",
+		quoteIdent(fk.Name), quote(fk.Columns), quoteIdent(fk.RefTable), quote(fk.RefColumns), fk.OnDelete, fk.OnUpdate)
+}
+
+func findIndex(t *Table, name string) *Index {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+
+	return nil
+}
+
+func findForeignKey(t *Table, name string) *ForeignKey {
+	for _, fk := range t.ForeignKeys {
+		if fk.Name == name {
+			return fk
+		}
+	}
+
+	return nil
+}
+
+// charsetOf 由排序规则得到字符集，如 utf8mb4_general_ci 为 utf8mb4
+func charsetOf(collation string) string {
+	return strings.SplitN(collation, "_", 2)[0]
+}
+
+func init() {
+	diff.Flags().String("from", "", "作为标准的库")
+	diff.Flags().String("to", "", "需要与标准一致的库")
+	diff.Flags().StringP("output", "o", "", "将 ALTER 语句保存到文件")
+	diff.Flags().Bool("ignore-auto-increment", false, "忽略自增值的差异")
+	diff.Flags().Bool("ignore-comments", false, "忽略表和列注释的差异")
+	diff.MarkFlagRequired("from")
+	diff.MarkFlagRequired("to")
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var drop = &cobra.Command{
+	Use:   "drop <name>",
+	Short: "删除数据库",
+	Long:  color.Success.Render("\r\n删除数据库。需要输入数据库名称确认，删除前自动导出到本地"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		confirmName, _ := cmd.Flags().GetString("confirm")
+		noDump, _ := cmd.Flags().GetBool("no-dump")
+		dir, _ := cmd.Flags().GetString("dump-dir")
+
+		if err := validateIdent(name); err != nil {
+			return err
+		}
+		if systemSchemas[strings.ToLower(name)] {
+			return errors.New("不能删除系统库：" + name)
+		}
+
+		db, cfg, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if !schemaExists(db, name) {
+			return errors.New("数据库不存在：" + name)
+		}
+
+		if confirmName == "" {
+			color.Warnln("即将删除数据库 " + name + "，此操作不可恢复")
+			fmt.Print("请输入数据库名称以确认：")
+			confirmName, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+			confirmName = strings.TrimSpace(confirmName)
+		}
+		if confirmName != name {
+			return errors.New("输入的名称不一致，已取消")
+		}
+
+		if !noDump {
+			color.Blueln("删除前导出数据库 " + name)
+			path, err := dumpToFile(cfg, name, dir)
+			if err != nil {
+				return fmt.Errorf("导出失败，已取消删除：%s", err)
+			}
+			color.Infoln("已导出到：" + path)
+		}
+
+		if _, err := db.Exec("DROP DATABASE " + quoteIdent(name)); err != nil {
+			return fmt.Errorf("删除数据库失败：%s", err)
+		}
+		color.Infoln("已删除数据库：" + name)
+
+		return nil
+	},
+}
+
+// schemaExists 判断数据库是否存在
+func schemaExists(db queryer, name string) bool {
+	var found string
+	err := db.QueryRow("SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", name).Scan(&found)
+
+	return err == nil
+}
+
+func init() {
+	drop.Flags().String("confirm", "", "用于脚本中的确认，需要与数据库名称相同")
+	drop.Flags().Bool("no-dump", false, "删除前不导出")
+	drop.Flags().String("dump-dir", defaultDumpDir(), "删除前导出的保存目录")
+}
@@ -0,0 +1,573 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jarvis/cmd/shared"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// 单条INSERT语句的最大长度，低于 max_allowed_packet 的默认值
+const maxStatement = 1 << 20
+
+// dumpOptions 导出选项，表名支持通配符
+type dumpOptions struct {
+	Include []string
+	Exclude []string
+	NoData  bool
+	Batch   int
+	// NoRoutines 不导出存储过程、函数及事件
+	NoRoutines bool
+}
+
+var dump = &cobra.Command{
+	Use:   "dump <db>",
+	Short: "导出数据库为SQL",
+	Long:  color.Success.Render("\r\n不依赖 mysqldump 导出表结构、数据、视图、触发器、存储过程、函数及事件。\r\nInnoDB 表在一致性快照中导出，文件名以 .gz 结尾时使用gzip压缩"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		output, _ := cmd.Flags().GetString("output")
+		opts := dumpOptions{}
+		opts.Include, _ = cmd.Flags().GetStringSlice("tables")
+		opts.Exclude, _ = cmd.Flags().GetStringSlice("exclude")
+		opts.NoData, _ = cmd.Flags().GetBool("no-data")
+		opts.Batch, _ = cmd.Flags().GetInt("batch")
+		opts.NoRoutines, _ = cmd.Flags().GetBool("no-routines")
+
+		if err := validateIdent(name); err != nil {
+			return err
+		}
+		if output == "" {
+			output = name + "-" + time.Now().Format("20060102-150405") + ".sql.gz"
+		}
+
+		cfg, err := resolveConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		if err := writeDump(cfg, name, output, opts); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(output)
+		if err != nil {
+			return err
+		}
+		color.Success.Println(fmt.Sprintf("\r\n已导出到 %s（%s，用时 %s）", output, shared.FormatBytes(info.Size()), time.Since(start).Round(time.Second)))
+
+		return nil
+	},
+}
+
+// dumpToFile 将数据库导出为 dir 目录下带时间戳的 .sql.gz 文件
+func dumpToFile(cfg Config, name string, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name+"-"+time.Now().Format("20060102-150405")+".sql.gz")
+
+	return path, writeDump(cfg, name, path, dumpOptions{})
+}
+
+// writeDump 导出到文件，以 .gz 结尾时压缩，失败时删除不完整的文件
+func writeDump(cfg Config, name string, path string, opts dumpOptions) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = file
+	var zw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		zw = gzip.NewWriter(file)
+		w = zw
+	}
+
+	err = dumpDatabase(cfg, name, w, opts)
+	if zw != nil {
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+
+	return err
+}
+
+// dumpDatabase 在一致性快照事务中导出数据库
+func dumpDatabase(cfg Config, name string, w io.Writer, opts dumpOptions) error {
+	if opts.Batch <= 0 {
+		opts.Batch = 1000
+	}
+
+	cfg.Database = name
+	// 不解析时间，零值日期等按原样导出
+	db, err := openWith(cfg, func(c *mysql.Config) { c.ParseTime = false })
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, query := range []string{
+		"SET SESSION time_zone = '+00:00'",
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT",
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("开启快照事务失败：%s", err)
+		}
+	}
+	defer conn.ExecContext(ctx, "ROLLBACK")
+
+	d := &dumper{ctx: ctx, conn: conn, out: bufio.NewWriterSize(w, 1<<20), opts: opts}
+	if err := d.run(name); err != nil {
+		return err
+	}
+
+	return d.out.Flush()
+}
+
+type dumper struct {
+	ctx  context.Context
+	conn *sql.Conn
+	out  *bufio.Writer
+	opts dumpOptions
+}
+
+func (d *dumper) run(name string) error {
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT TABLE_NAME, TABLE_TYPE, IFNULL(ENGINE, '') FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME", name)
+	if err != nil {
+		return err
+	}
+	var tables, views, nonTransactional []string
+	for rows.Next() {
+		var table, typ, engine string
+		if err := rows.Scan(&table, &typ, &engine); err != nil {
+			rows.Close()
+			return err
+		}
+		if !d.selected(table) {
+			continue
+		}
+		if typ == "VIEW" {
+			views = append(views, table)
+			continue
+		}
+		tables = append(tables, table)
+		if engine != "InnoDB" {
+			nonTransactional = append(nonTransactional, table)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(nonTransactional) > 0 {
+		color.Warnln("以下表不是InnoDB，导出时数据可能不一致：" + strings.Join(nonTransactional, ", "))
+	}
+
+	fmt.Fprintf(d.out, "-- jarvis dump of %s at %s\n\n", quoteIdent(name), time.Now().Format(time.RFC3339))
+	d.out.WriteString("SET NAMES utf8mb4;\nSET time_zone = '+00:00';\nSET FOREIGN_KEY_CHECKS = 0;\nSET UNIQUE_CHECKS = 0;\nSET SQL_MODE = 'NO_AUTO_VALUE_ON_ZERO';\n\n")
+
+	for _, table := range tables {
+		create, err := d.showCreate("SHOW CREATE TABLE "+quoteIdent(table), 1)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(d.out, "DROP TABLE IF EXISTS %s;\n%s;\n\n", quoteIdent(table), create)
+
+		if d.opts.NoData {
+			continue
+		}
+		count, err := d.dumpRows(name, table)
+		if err != nil {
+			return fmt.Errorf("导出表 %s 失败：%s", table, err)
+		}
+		color.Infoln(fmt.Sprintf("  %s：%d 行", table, count))
+	}
+
+	for _, view := range views {
+		create, err := d.showCreate("SHOW CREATE VIEW "+quoteIdent(view), 1)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(d.out, "DROP VIEW IF EXISTS %s;\n%s;\n\n", quoteIdent(view), stripDefiner(create))
+	}
+
+	if err := d.dumpTriggers(name, tables); err != nil {
+		return err
+	}
+	if !d.opts.NoRoutines {
+		if err := d.dumpRoutines(name); err != nil {
+			return err
+		}
+		if err := d.dumpEvents(name); err != nil {
+			return err
+		}
+	}
+
+	d.out.WriteString("SET FOREIGN_KEY_CHECKS = 1;\nSET UNIQUE_CHECKS = 1;\n")
+
+	return nil
+}
+
+// selected 按包含及排除规则判断是否导出表
+func (d *dumper) selected(table string) bool {
+	for _, pattern := range d.opts.Exclude {
+		if ok, _ := path.Match(pattern, table); ok {
+			return false
+		}
+	}
+	if len(d.opts.Include) == 0 {
+		return true
+	}
+	for _, pattern := range d.opts.Include {
+		if ok, _ := path.Match(pattern, table); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// showCreate 执行 SHOW CREATE 语句并返回指定列
+func (d *dumper) showCreate(query string, column int) (string, error) {
+	values, err := d.showCreateRow(query)
+	if err != nil {
+		return "", err
+	}
+	// 没有权限查看存储过程等的定义时该列为 NULL
+	if !values[column].Valid {
+		return "", errors.New("没有权限读取定义：" + query)
+	}
+
+	return values[column].String, nil
+}
+
+// showCreateRow 执行 SHOW CREATE 语句并返回整行
+func (d *dumper) showCreateRow(query string) ([]sql.NullString, error) {
+	rows, err := d.conn.QueryContext(d.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("没有结果：" + query)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// dumpRows 按批生成多行INSERT语句，跳过生成列
+func (d *dumper) dumpRows(schema string, table string) (int, error) {
+	columns, err := d.columns(schema, table)
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdent(column)
+	}
+	list := strings.Join(quoted, ", ")
+
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT "+list+" FROM "+quoteIdent(table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	prefix := "INSERT INTO " + quoteIdent(table) + " (" + list + ") VALUES\n"
+	var b strings.Builder
+	total, batch := 0, 0
+	flush := func() error {
+		if batch == 0 {
+			return nil
+		}
+		b.WriteString(";\n")
+		_, err := d.out.WriteString(b.String())
+		b.Reset()
+		batch = 0
+		return err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return total, err
+		}
+		if batch == 0 {
+			b.WriteString(prefix)
+		} else {
+			b.WriteString(",\n")
+		}
+		b.WriteString("(")
+		for i, value := range values {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(sqlValue(value, types[i].DatabaseTypeName()))
+		}
+		b.WriteString(")")
+		batch++
+		total++
+
+		if batch >= d.opts.Batch || b.Len() >= maxStatement {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return total, err
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+	d.out.WriteString("\n")
+
+	return total, nil
+}
+
+// columns 表中可以插入的列，不含生成列。MySQL 8 中表达式默认值的列标记为 DEFAULT_GENERATED，需要导出
+func (d *dumper) columns(schema string, table string) ([]string, error) {
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND EXTRA NOT IN ('VIRTUAL GENERATED', 'STORED GENERATED') ORDER BY ORDINAL_POSITION", schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// dumpTriggers 导出所选表上的触发器，使用 DELIMITER 包裹触发器中的分号
+func (d *dumper) dumpTriggers(schema string, tables []string) error {
+	selected := map[string]bool{}
+	for _, table := range tables {
+		selected[table] = true
+	}
+
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT TRIGGER_NAME, EVENT_OBJECT_TABLE FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ? ORDER BY EVENT_OBJECT_TABLE, ACTION_ORDER", schema)
+	if err != nil {
+		return err
+	}
+	var triggers []string
+	for rows.Next() {
+		var trigger, table string
+		if err := rows.Scan(&trigger, &table); err != nil {
+			rows.Close()
+			return err
+		}
+		if selected[table] {
+			triggers = append(triggers, trigger)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, trigger := range triggers {
+		create, err := d.showCreate("SHOW CREATE TRIGGER "+quoteIdent(trigger), 2)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(d.out, "DROP TRIGGER IF EXISTS %s;\nDELIMITER ;;\n%s;;\nDELIMITER ;\n\n", quoteIdent(trigger), stripDefiner(create))
+	}
+
+	return nil
+}
+
+// dumpRoutines 导出存储过程及函数，创建时使用定义时的 sql_mode
+func (d *dumper) dumpRoutines(schema string) error {
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT ROUTINE_TYPE, ROUTINE_NAME FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ? ORDER BY ROUTINE_TYPE, ROUTINE_NAME", schema)
+	if err != nil {
+		return err
+	}
+	var types, names []string
+	for rows.Next() {
+		var typ, name string
+		if err := rows.Scan(&typ, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		types = append(types, typ)
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		// SHOW CREATE PROCEDURE/FUNCTION 的列：名称、sql_mode、定义
+		values, err := d.showCreateRow("SHOW CREATE " + types[i] + " " + quoteIdent(name))
+		if err != nil {
+			return err
+		}
+		if !values[2].Valid {
+			return fmt.Errorf("没有权限读取 %s 的定义，可以使用 --no-routines 跳过", name)
+		}
+		fmt.Fprintf(d.out, "DROP %s IF EXISTS %s;\nSET SESSION sql_mode = %s;\nDELIMITER ;;\n%s;;\nDELIMITER ;\n\n",
+			types[i], quoteIdent(name), quoteString(values[1].String), stripDefiner(values[2].String))
+	}
+	if len(names) > 0 {
+		d.out.WriteString("SET SQL_MODE = 'NO_AUTO_VALUE_ON_ZERO';\n\n")
+	}
+
+	return nil
+}
+
+// dumpEvents 导出事件，创建时使用定义时的 sql_mode 及时区
+func (d *dumper) dumpEvents(schema string) error {
+	rows, err := d.conn.QueryContext(d.ctx, "SELECT EVENT_NAME FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ? ORDER BY EVENT_NAME", schema)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		// SHOW CREATE EVENT 的列：名称、sql_mode、时区、定义
+		values, err := d.showCreateRow("SHOW CREATE EVENT " + quoteIdent(name))
+		if err != nil {
+			return err
+		}
+		if !values[3].Valid {
+			return fmt.Errorf("没有权限读取事件 %s 的定义，可以使用 --no-routines 跳过", name)
+		}
+		fmt.Fprintf(d.out, "DROP EVENT IF EXISTS %s;\nSET SESSION sql_mode = %s;\nSET SESSION time_zone = %s;\nDELIMITER ;;\n%s;;\nDELIMITER ;\n\n",
+			quoteIdent(name), quoteString(values[1].String), quoteString(values[2].String), stripDefiner(values[3].String))
+	}
+	if len(names) > 0 {
+		d.out.WriteString("SET SQL_MODE = 'NO_AUTO_VALUE_ON_ZERO';\nSET time_zone = '+00:00';\n\n")
+	}
+
+	return nil
+}
+
+var definerPattern = regexp.MustCompile("DEFINER=`(?:[^`]|``)*`@`(?:[^`]|``)*` ")
+
+// stripDefiner 去掉视图、触发器、存储过程及事件的 DEFINER，导入到其他服务器时使用当前账号
+func stripDefiner(create string) string {
+	return definerPattern.ReplaceAllString(create, "")
+}
+
+// sqlValue 将查询到的原始值转换为SQL字面量
+func sqlValue(value sql.RawBytes, typ string) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	switch typ {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "DECIMAL", "FLOAT", "DOUBLE", "YEAR":
+		return string(value)
+	case "BIT", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+		if len(value) == 0 {
+			return "''"
+		}
+		return "0x" + hex.EncodeToString(value)
+	}
+
+	return quoteString(string(value))
+}
+
+// defaultDumpDir 删除前自动导出的默认目录 ~/.jarvis/database/dumps
+func defaultDumpDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "dumps"
+	}
+
+	return filepath.Join(home, ".jarvis", "database", "dumps")
+}
+
+func init() {
+	dump.Flags().StringP("output", "o", "", "输出文件，默认为 <db>-<时间>.sql.gz")
+	dump.Flags().StringSlice("tables", nil, "只导出这些表，支持通配符，如 wp_*")
+	dump.Flags().StringSlice("exclude", nil, "不导出这些表，支持通配符")
+	dump.Flags().Bool("no-data", false, "只导出表结构")
+	dump.Flags().Int("batch", 1000, "每条INSERT语句包含的最大行数")
+	dump.Flags().Bool("no-routines", false, "不导出存储过程、函数及事件")
+}
@@ -0,0 +1,393 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// relation 表之间的引用，Inferred 为按 *_id 命名推断的关系
+type relation struct {
+	Table      string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	Nullable   bool
+	Unique     bool
+	Inferred   bool
+}
+
+var erd = &cobra.Command{
+	Use:   "erd <db>",
+	Short: "生成ER图",
+	Long: color.Success.Render("\r\n读取表、列、主键及外键，生成 Mermaid、Graphviz dot 或 PlantUML 格式的ER图。\r\n" +
+		"没有外键约束时可以使用 --infer 按 xxx_id 命名推断表之间的关系"),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		infer, _ := cmd.Flags().GetBool("infer")
+		keysOnly, _ := cmd.Flags().GetBool("keys-only")
+
+		var render func(io.Writer, *Schema, []string, []relation, bool) error
+		switch format {
+		case "mermaid":
+			render = renderMermaid
+		case "dot":
+			render = renderDot
+		case "plantuml":
+			render = renderPlantUML
+		default:
+			return fmt.Errorf("不支持的格式：%s，可选 mermaid、dot、plantuml", format)
+		}
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		schema, err := loadSchema(db, args[0])
+		if err != nil {
+			return err
+		}
+
+		var tables []string
+		for _, name := range schema.TableNames() {
+			if hasPrefix(name, prefixes) {
+				tables = append(tables, name)
+			}
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("%s 中没有符合条件的表", args[0])
+		}
+
+		relations := schemaRelations(schema, tables, prefixes, infer)
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+		if err := render(w, schema, tables, relations, keysOnly); err != nil {
+			return err
+		}
+		if output != "" {
+			color.Infoln(fmt.Sprintf("已将 %d 个表、%d 个关系保存到：%s", len(tables), len(relations), output))
+		}
+
+		return nil
+	},
+}
+
+// hasPrefix 没有指定前缀时匹配所有表
+func hasPrefix(name string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schemaRelations 所选表之间的外键关系，infer 时为没有外键的 xxx_id 列推断引用的表
+func schemaRelations(schema *Schema, tables []string, prefixes []string, infer bool) []relation {
+	selected := map[string]bool{}
+	for _, name := range tables {
+		selected[name] = true
+	}
+
+	var relations []relation
+	for _, name := range tables {
+		t := schema.Tables[name]
+		covered := map[string]bool{}
+		for _, fk := range t.ForeignKeys {
+			for _, column := range fk.Columns {
+				covered[column] = true
+			}
+			if !selected[fk.RefTable] {
+				continue
+			}
+			relations = append(relations, newRelation(t, fk.Columns, fk.RefTable, fk.RefColumns, false))
+		}
+
+		if !infer {
+			continue
+		}
+		for _, c := range t.Columns {
+			if covered[c.Name] {
+				continue
+			}
+			if parent := inferParent(schema, t, c.Name, prefixes); parent != nil && selected[parent.Name] {
+				relations = append(relations, newRelation(t, []string{c.Name}, parent.Name, parent.PrimaryKey(), true))
+			}
+		}
+	}
+
+	return relations
+}
+
+func newRelation(t *Table, columns []string, refTable string, refColumns []string, inferred bool) relation {
+	r := relation{Table: t.Name, Columns: columns, RefTable: refTable, RefColumns: refColumns, Inferred: inferred}
+	for _, name := range columns {
+		if c := t.Column(name); c != nil && c.Nullable {
+			r.Nullable = true
+		}
+	}
+	// 引用列上有唯一索引时为一对一
+	for _, index := range t.Indexes {
+		if index.Unique && strings.Join(index.Columns, ",") == strings.Join(columns, ",") {
+			r.Unique = true
+		}
+	}
+
+	return r
+}
+
+var (
+	idColumn  = regexp.MustCompile(`^(.+)(_id|Id|ID)$`)
+	camelWord = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// inferParent 按列名推断引用的表：user_id、userId 依次尝试 user、users、前缀+users 等，parent_id 指向本表，
+// 被引用的表需要有单列主键
+func inferParent(schema *Schema, t *Table, column string, prefixes []string) *Table {
+	match := idColumn.FindStringSubmatch(column)
+	if match == nil {
+		return nil
+	}
+	base := strings.ToLower(camelWord.ReplaceAllString(match[1], "${1}_${2}"))
+
+	var candidates []string
+	for _, name := range []string{base, base + "s", base + "es", strings.TrimSuffix(base, "y") + "ies"} {
+		candidates = append(candidates, name)
+		for _, prefix := range prefixes {
+			candidates = append(candidates, prefix+name)
+		}
+		// 与当前表使用相同的前缀，如 wp_posts.author_id -> wp_authors
+		if i := strings.Index(t.Name, "_"); i > 0 {
+			candidates = append(candidates, t.Name[:i+1]+name)
+		}
+	}
+	if base == "parent" {
+		candidates = append(candidates, t.Name)
+	}
+
+	for _, name := range candidates {
+		for _, table := range schema.Tables {
+			if strings.EqualFold(table.Name, name) && len(table.PrimaryKey()) == 1 && (table != t || base == "parent") {
+				return table
+			}
+		}
+	}
+
+	return nil
+}
+
+// erdColumn 列的标记：主键、外键
+type erdColumn struct {
+	*Column
+	PK bool
+	FK bool
+}
+
+// erdColumns 表中需要展示的列，keysOnly 时只包括主键及外键
+func erdColumns(t *Table, relations []relation, keysOnly bool) []erdColumn {
+	pk, fk := map[string]bool{}, map[string]bool{}
+	for _, column := range t.PrimaryKey() {
+		pk[strings.SplitN(column, "(", 2)[0]] = true
+	}
+	for _, r := range relations {
+		if r.Table == t.Name {
+			for _, column := range r.Columns {
+				fk[column] = true
+			}
+		}
+	}
+
+	var columns []erdColumn
+	for _, c := range t.Columns {
+		if keysOnly && !pk[c.Name] && !fk[c.Name] {
+			continue
+		}
+		columns = append(columns, erdColumn{Column: c, PK: pk[c.Name], FK: fk[c.Name]})
+	}
+
+	return columns
+}
+
+var erdName = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// baseType 去掉长度及属性的类型，如 varchar(255) -> varchar
+func baseType(typ string) string {
+	return strings.Fields(strings.SplitN(typ, "(", 2)[0])[0]
+}
+
+func renderMermaid(w io.Writer, schema *Schema, tables []string, relations []relation, keysOnly bool) error {
+	id := func(name string) string { return erdName.ReplaceAllString(name, "_") }
+
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, name := range tables {
+		b.WriteString("    " + id(name) + " {\n")
+		for _, c := range erdColumns(schema.Tables[name], relations, keysOnly) {
+			var keys []string
+			if c.PK {
+				keys = append(keys, "PK")
+			}
+			if c.FK {
+				keys = append(keys, "FK")
+			}
+			b.WriteString("        " + id(baseType(c.Type)) + " " + id(c.Name))
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ","))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	for _, r := range relations {
+		parent, child, line := "||", "o{", "--"
+		if r.Nullable {
+			parent = "|o"
+		}
+		if r.Unique {
+			child = "o|"
+		}
+		if r.Inferred {
+			line = ".."
+		}
+		fmt.Fprintf(&b, "    %s %s%s%s %s : %q\n", id(r.RefTable), parent, line, child, id(r.Table), strings.Join(r.Columns, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderDot(w io.Writer, schema *Schema, tables []string, relations []relation, keysOnly bool) error {
+	quote := func(s string) string { return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"` }
+
+	var b strings.Builder
+	b.WriteString("digraph erd {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=plaintext, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [dir=both, arrowtail=crow, arrowhead=tee];\n\n")
+	for _, name := range tables {
+		b.WriteString("  " + quote(name) + " [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\" cellpadding=\"4\">\n")
+		b.WriteString("    <tr><td bgcolor=\"#dddddd\"><b>" + html.EscapeString(name) + "</b></td></tr>\n")
+		for _, c := range erdColumns(schema.Tables[name], relations, keysOnly) {
+			label := html.EscapeString(c.Name) + " : " + html.EscapeString(c.Type)
+			if c.PK {
+				label = "<u>" + label + "</u>"
+			}
+			if c.FK {
+				label += " <i>FK</i>"
+			}
+			b.WriteString("    <tr><td port=" + quote(c.Name) + " align=\"left\">" + label + "</td></tr>\n")
+		}
+		b.WriteString("  </table>>];\n")
+	}
+	b.WriteString("\n")
+
+	for _, r := range relations {
+		var attrs []string
+		if r.Unique {
+			attrs = append(attrs, "arrowtail=tee")
+		}
+		if r.Nullable {
+			attrs = append(attrs, "arrowhead=teeodot")
+		}
+		if r.Inferred {
+			attrs = append(attrs, "style=dashed")
+		}
+		edge := "  " + quote(r.Table) + ":" + quote(r.Columns[0]) + " -> " + quote(r.RefTable)
+		if len(r.RefColumns) > 0 {
+			edge += ":" + quote(r.RefColumns[0])
+		}
+		if len(attrs) > 0 {
+			edge += " [" + strings.Join(attrs, ", ") + "]"
+		}
+		b.WriteString(edge + ";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderPlantUML(w io.Writer, schema *Schema, tables []string, relations []relation, keysOnly bool) error {
+	id := func(name string) string { return erdName.ReplaceAllString(name, "_") }
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("hide circle\n")
+	b.WriteString("skinparam linetype ortho\n\n")
+	for _, name := range tables {
+		columns := erdColumns(schema.Tables[name], relations, keysOnly)
+		sort.SliceStable(columns, func(i, j int) bool { return columns[i].PK && !columns[j].PK })
+
+		fmt.Fprintf(&b, "entity %q as %s {\n", name, id(name))
+		// 主键列在分隔线之上，没有主键时不需要分隔线
+		separated := len(columns) == 0 || !columns[0].PK
+		for _, c := range columns {
+			if !c.PK && !separated {
+				b.WriteString("  --\n")
+				separated = true
+			}
+			line := "  " + c.Name + " : " + c.Type
+			if c.PK {
+				line = "  * " + c.Name + " : " + c.Type + " <<PK>>"
+			} else if !c.Nullable {
+				line = "  * " + c.Name + " : " + c.Type
+			}
+			if c.FK {
+				line += " <<FK>>"
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	for _, r := range relations {
+		parent, child, line := "||", "o{", "--"
+		if r.Nullable {
+			parent = "|o"
+		}
+		if r.Unique {
+			child = "o|"
+		}
+		if r.Inferred {
+			line = ".."
+		}
+		fmt.Fprintf(&b, "%s %s%s%s %s : %s\n", id(r.RefTable), parent, line, child, id(r.Table), strings.Join(r.Columns, ", "))
+	}
+	b.WriteString("@enduml\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func init() {
+	erd.Flags().StringP("format", "f", "mermaid", "输出格式：mermaid、dot、plantuml")
+	erd.Flags().StringP("output", "o", "", "保存到文件")
+	erd.Flags().StringSlice("prefix", nil, "只包括以这些前缀开头的表，多个用逗号分隔")
+	erd.Flags().Bool("infer", false, "按 xxx_id 命名推断没有外键约束的关系")
+	erd.Flags().Bool("keys-only", false, "只展示主键及外键列")
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// presets 权限预设
+var presets = map[string][]string{
+	"readonly":  {"SELECT", "SHOW VIEW"},
+	"readwrite": {"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE TEMPORARY TABLES", "LOCK TABLES", "EXECUTE", "SHOW VIEW"},
+	"admin":     {"ALL PRIVILEGES"},
+}
+
+var grant = &cobra.Command{
+	Use:       "grant <preset>",
+	Short:     "按预设为账号授予数据库权限",
+	Long:      color.Success.Render("\r\n按预设为账号授予数据库权限：\r\n  readonly   只读\r\n  readwrite  读写数据，不能修改表结构\r\n  admin      全部权限"),
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: presetNames(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, from, dbName := grantTarget(cmd)
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := grantPreset(db, args[0], dbName, name, from); err != nil {
+			return err
+		}
+		color.Infoln("已授予 " + name + "@" + from + " " + args[0] + " 权限：" + dbName)
+
+		return nil
+	},
+}
+
+var revoke = &cobra.Command{
+	Use:       "revoke <preset>",
+	Short:     "按预设撤销账号的数据库权限",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: presetNames(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, from, dbName := grantTarget(cmd)
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := checkBackslash(db, name, from); err != nil {
+			return err
+		}
+		query := "REVOKE " + strings.Join(presets[args[0]], ", ") + " ON " + scope(dbName) + " FROM " + account(name, from)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("撤销权限失败：%s", err)
+		}
+		color.Infoln("已撤销 " + name + "@" + from + " " + args[0] + " 权限：" + dbName)
+
+		return nil
+	},
+}
+
+var grants = &cobra.Command{
+	Use:   "grants",
+	Short: "查看账号的有效权限",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("user")
+		from, _ := cmd.Flags().GetString("user-host")
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := checkBackslash(db, name, from); err != nil {
+			return err
+		}
+		rows, err := db.Query("SHOW GRANTS FOR " + account(name, from))
+		if err != nil {
+			return fmt.Errorf("查询权限失败：%s", err)
+		}
+		defer rows.Close()
+
+		color.Infoln(name + "@" + from + " 的权限：")
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				return err
+			}
+			// 旧版本的 SHOW GRANTS 会带出密码哈希
+			if i := strings.Index(line, " IDENTIFIED BY PASSWORD"); i >= 0 {
+				line = line[:i]
+			}
+			color.Println("  " + line)
+		}
+
+		return rows.Err()
+	},
+}
+
+// grantPreset 将预设中的权限授予账号
+func grantPreset(db *sql.DB, preset string, dbName string, name string, from string) error {
+	privileges, ok := presets[preset]
+	if !ok {
+		return fmt.Errorf("不支持的权限预设：%s，可选 %s", preset, strings.Join(presetNames(), "、"))
+	}
+
+	if err := checkBackslash(db, name, from); err != nil {
+		return err
+	}
+	query := "GRANT " + strings.Join(privileges, ", ") + " ON " + scope(dbName) + " TO " + account(name, from)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("授权失败：%s", err)
+	}
+
+	return nil
+}
+
+// scope 授权范围，* 表示全部数据库
+func scope(dbName string) string {
+	if dbName == "*" {
+		return "*.*"
+	}
+
+	return quoteIdent(dbName) + ".*"
+}
+
+func grantTarget(cmd *cobra.Command) (string, string, string) {
+	name, _ := cmd.Flags().GetString("user")
+	from, _ := cmd.Flags().GetString("user-host")
+	dbName, _ := cmd.Flags().GetString("db")
+
+	return name, from, dbName
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func init() {
+	for _, c := range []*cobra.Command{grant, revoke, grants} {
+		c.Flags().String("user", "", "账号名称")
+		c.Flags().String("user-host", "%", "账号允许登录的来源主机")
+		c.MarkFlagRequired("user")
+	}
+	for _, c := range []*cobra.Command{grant, revoke} {
+		c.Flags().String("db", "", "数据库名称，* 表示全部")
+		c.MarkFlagRequired("db")
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// quoteIdent 用反引号包裹库名、表名等标识符，内部的反引号加倍转义
+func quoteIdent(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// quoteString 生成单引号字符串字面量，用于不支持占位符的语句，如 CREATE USER。
+// 单引号写两次，开启 NO_BACKSLASH_ESCAPES 时字面量同样不会提前结束
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`).Replace(value) + "'"
+}
+
+// checkBackslash 开启 NO_BACKSLASH_ESCAPES 时反斜杠不转义，quoteString 生成的字面量与原值不同，
+// 账号名及密码中包含反斜杠时拒绝执行
+func checkBackslash(db queryer, values ...string) error {
+	found := false
+	for _, value := range values {
+		if strings.ContainsAny(value, "\\\x00") {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var mode string
+	if err := db.QueryRow("SELECT @@SESSION.sql_mode").Scan(&mode); err != nil {
+		return err
+	}
+	if strings.Contains(mode, "NO_BACKSLASH_ESCAPES") {
+		return errors.New("服务器开启了 NO_BACKSLASH_ESCAPES，账号名及密码中不能包含反斜杠")
+	}
+
+	return nil
+}
+
+// account 生成 'user'@'host' 形式的账号
+func account(user string, host string) string {
+	return quoteString(user) + "@" + quoteString(host)
+}
+
+// systemSchemas MySQL自带的库，不允许删除或重命名
+var systemSchemas = map[string]bool{
+	"mysql":              true,
+	"information_schema": true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+var charsetPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// validateIdent 检查库名是否合法：1到64个字符，不含控制字符、/、\、. 且不以空格结尾
+func validateIdent(name string) error {
+	if name == "" {
+		return errors.New("名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > 64 {
+		return fmt.Errorf("名称不能超过64个字符：%s", name)
+	}
+	if strings.HasSuffix(name, " ") {
+		return fmt.Errorf("名称不能以空格结尾：%q", name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || r == '/' || r == '\\' || r == '.' {
+			return fmt.Errorf("名称不能包含控制字符、/、\\ 或 .：%q", name)
+		}
+	}
+
+	return nil
+}
+
+// validateCharset 检查字符集及排序规则名称，只允许字母、数字和下划线
+func validateCharset(name string) error {
+	if name != "" && !charsetPattern.MatchString(name) {
+		return fmt.Errorf("字符集或排序规则不正确：%s", name)
+	}
+
+	return nil
+}
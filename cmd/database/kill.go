@@ -0,0 +1,115 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"jarvis/cmd/shared"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var kill = &cobra.Command{
+	Use:   "kill",
+	Short: "终止执行时间过长的语句",
+	Long:  color.Success.Render("\r\n按执行时间、用户、数据库及语句内容筛选线程，列出后确认再终止。\r\n默认只终止正在执行的语句，终止前再次确认线程仍在执行同一条语句"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		longerThan, _ := cmd.Flags().GetDuration("longer-than")
+		user, _ := cmd.Flags().GetString("user")
+		name, _ := cmd.Flags().GetString("db")
+		match, _ := cmd.Flags().GetString("match")
+		includeSleep, _ := cmd.Flags().GetBool("include-sleep")
+		connection, _ := cmd.Flags().GetBool("connection")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if longerThan <= 0 && user == "" && name == "" && match == "" {
+			return errors.New("请至少指定 --longer-than、--user、--db 或 --match 中的一个条件")
+		}
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		processes, err := listProcesses(db, includeSleep)
+		if err != nil {
+			return err
+		}
+
+		var matched []process
+		for _, p := range processes {
+			if time.Duration(p.Time)*time.Second < longerThan {
+				continue
+			}
+			if user != "" && p.User != user {
+				continue
+			}
+			if name != "" && p.DB != name {
+				continue
+			}
+			if match != "" && !strings.Contains(strings.ToLower(p.Info), strings.ToLower(match)) {
+				continue
+			}
+			matched = append(matched, p)
+		}
+		if len(matched) == 0 {
+			color.Infoln("没有符合条件的线程")
+			return nil
+		}
+
+		printProcesses(matched, 0)
+		fmt.Println()
+
+		statement, action := "KILL QUERY ", "终止以上语句"
+		if connection {
+			statement, action = "KILL CONNECTION ", "断开以上连接"
+		}
+		if !yes && !shared.Confirm(fmt.Sprintf("确定%s（%d 个）？", action, len(matched))) {
+			return errors.New("已取消")
+		}
+
+		failed := 0
+		for _, p := range matched {
+			// 确认期间线程可能已经执行了其他语句，连接池中的连接尤其如此
+			if !unchanged(db, p) {
+				color.Warnln(fmt.Sprintf("线程 %d 已经结束或正在执行其他语句，已跳过", p.ID))
+				continue
+			}
+			if _, err := db.Exec(statement + fmt.Sprint(p.ID)); err != nil {
+				// 线程可能已经结束
+				color.Warnln(fmt.Sprintf("终止 %d 失败：%s", p.ID, err))
+				failed++
+				continue
+			}
+			color.Infoln(fmt.Sprintf("已终止：%d", p.ID))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d 个线程终止失败", failed)
+		}
+
+		return nil
+	},
+}
+
+// unchanged 线程是否仍在执行列出时的语句：语句相同且执行时间没有变短
+func unchanged(db *sql.DB, p process) bool {
+	var info string
+	var seconds int64
+	err := db.QueryRow("SELECT IFNULL(INFO, ''), IFNULL(TIME, 0) FROM information_schema.PROCESSLIST WHERE ID = ?", p.ID).Scan(&info, &seconds)
+
+	return err == nil && info == p.Info && seconds >= p.Time
+}
+
+func init() {
+	kill.Flags().Duration("longer-than", 0, "执行时间超过该值，例如 60s")
+	kill.Flags().String("user", "", "只终止该用户的线程")
+	kill.Flags().String("db", "", "只终止该数据库的线程")
+	kill.Flags().String("match", "", "语句中包含的内容，不区分大小写")
+	kill.Flags().Bool("include-sleep", false, "包括空闲的连接")
+	kill.Flags().Bool("connection", false, "断开整个连接，默认只终止正在执行的语句")
+	kill.Flags().BoolP("yes", "y", false, "不询问直接终止")
+}
@@ -0,0 +1,325 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskRules 脱敏规则文件，未列出的列保持原值
+//
+//	salt: change-me             # 相同的盐及原值得到相同的结果，关联的列脱敏后仍然一致
+//	columns:                    # 按列名匹配所有的表
+//	  email: email
+//	  phone: hash
+//	tables:
+//	  users:
+//	    limit: 500              # 覆盖 --limit
+//	    where: "deleted_at IS NULL"
+//	    columns:
+//	      name: name
+//	      password: {fixed: "secret"}
+//	      remark: null
+//	  audit_logs:
+//	    skip: true              # 只创建表结构，不复制数据
+type maskRules struct {
+	Salt    string                `yaml:"salt"`
+	Columns map[string]maskRule   `yaml:"columns"`
+	Tables  map[string]tableRules `yaml:"tables"`
+}
+
+type tableRules struct {
+	Limit   *int                `yaml:"limit"`
+	Where   string              `yaml:"where"`
+	Skip    bool                `yaml:"skip"`
+	Columns map[string]maskRule `yaml:"columns"`
+}
+
+// maskRule 列的脱敏方式：email、name、hash、null、fixed、keep
+type maskRule struct {
+	Kind  string
+	Value string
+}
+
+// UnmarshalYAML 支持 email 这样的字符串，及 {fixed: value} 形式的固定值
+func (r *maskRule) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		r.Kind = strings.ToLower(node.Value)
+		if node.Tag == "!!null" {
+			r.Kind = "null"
+		}
+	case yaml.MappingNode:
+		var fields map[string]string
+		if err := node.Decode(&fields); err != nil {
+			return err
+		}
+		value, ok := fields["fixed"]
+		if len(fields) != 1 || !ok {
+			return fmt.Errorf("第 %d 行：固定值请使用 {fixed: value}", node.Line)
+		}
+		r.Kind, r.Value = "fixed", value
+	default:
+		return fmt.Errorf("第 %d 行：不支持的脱敏规则", node.Line)
+	}
+
+	switch r.Kind {
+	case "email", "name", "hash", "null", "fixed", "keep":
+		return nil
+	}
+
+	return fmt.Errorf("第 %d 行：不支持的脱敏方式 %s，可选 email、name、hash、null、fixed、keep", node.Line, r.Kind)
+}
+
+// loadMaskRules 读取规则文件
+func loadMaskRules(path string) (*maskRules, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &maskRules{}
+	if err := yaml.Unmarshal(content, rules); err != nil {
+		return nil, fmt.Errorf("解析脱敏规则失败：%s", err)
+	}
+
+	// 值为 null 时不会调用 UnmarshalYAML，得到空的规则
+	normalize := func(columns map[string]maskRule) {
+		for name, rule := range columns {
+			if rule.Kind == "" {
+				columns[name] = maskRule{Kind: "null"}
+			}
+		}
+	}
+	normalize(rules.Columns)
+	for _, table := range rules.Tables {
+		normalize(table.Columns)
+	}
+
+	return rules, nil
+}
+
+// rule 列使用的规则，表中的规则优先于按列名匹配的规则
+func (r *maskRules) rule(table, column string) maskRule {
+	if rule, ok := r.Tables[table].Columns[column]; ok {
+		return rule
+	}
+	if rule, ok := r.Columns[column]; ok {
+		return rule
+	}
+
+	return maskRule{Kind: "keep"}
+}
+
+// limit 表的抽样行数，0 表示全部
+func (r *maskRules) limit(table string, fallback int) int {
+	if limit := r.Tables[table].Limit; limit != nil {
+		return *limit
+	}
+
+	return fallback
+}
+
+// validate 检查规则中的表和列是否存在，及规则与列的类型是否匹配
+func (r *maskRules) validate(schema *Schema) (warnings []string, err error) {
+	for name, table := range r.Tables {
+		t := schema.Tables[name]
+		if t == nil {
+			warnings = append(warnings, "规则中的表不存在："+name)
+			continue
+		}
+		for column := range table.Columns {
+			if t.Column(column) == nil {
+				return warnings, fmt.Errorf("规则中的列不存在：%s.%s", name, column)
+			}
+		}
+	}
+
+	for _, name := range schema.TableNames() {
+		t := schema.Tables[name]
+		unique := uniqueColumns(t)
+		for _, c := range t.Columns {
+			rule := r.rule(name, c.Name)
+			switch {
+			case rule.Kind == "null" && !c.Nullable:
+				return warnings, fmt.Errorf("%s.%s 不允许为 NULL", name, c.Name)
+			case (rule.Kind == "email" || rule.Kind == "name") && !isTextType(c.Type):
+				return warnings, fmt.Errorf("%s.%s 的类型为 %s，不能使用 %s", name, c.Name, c.Type, rule.Kind)
+			case rule.Kind == "hash" && integerBits(c.Type) == 0 && !isTextType(c.Type):
+				return warnings, fmt.Errorf("%s.%s 的类型为 %s，hash 只能用于整数及文本列", name, c.Name, c.Type)
+			}
+			if unique[c.Name] && !distinctRule(rule, c) {
+				return warnings, fmt.Errorf("%s.%s 属于主键或唯一索引，%s 会产生重复值，可以改为 keep，或对足够长的列使用 hash、email", name, c.Name, rule.Kind)
+			}
+		}
+
+		// 外键两端的列需要使用相同的规则，脱敏后仍然可以关联，引用的列置为 NULL 时不影响关联
+		for _, fk := range t.ForeignKeys {
+			parent := schema.Tables[fk.RefTable]
+			if parent == nil {
+				continue
+			}
+			for i, column := range fk.Columns {
+				child, ref := t.Column(column), parent.Column(fk.RefColumns[i])
+				if child == nil || ref == nil {
+					continue
+				}
+				rule, refRule := r.rule(name, column), r.rule(fk.RefTable, ref.Name)
+				if rule.Kind == "null" {
+					continue
+				}
+				if rule != refRule || rule.Kind != "keep" && (child.Type != ref.Type || child.Charset != ref.Charset) {
+					return warnings, fmt.Errorf("外键 %s.%s 引用 %s.%s，两列需要使用相同的脱敏规则及类型", name, column, fk.RefTable, ref.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(warnings)
+
+	return warnings, nil
+}
+
+// uniqueColumns 主键及唯一索引中的列
+func uniqueColumns(t *Table) map[string]bool {
+	columns := map[string]bool{}
+	for _, index := range t.Indexes {
+		if !index.Unique {
+			continue
+		}
+		for _, column := range index.Columns {
+			columns[strings.SplitN(column, "(", 2)[0]] = true
+		}
+	}
+
+	return columns
+}
+
+// distinctRule 规则是否能为不同的原值生成不同的结果，取值空间不少于48位时认为不会重复
+func distinctRule(rule maskRule, c *Column) bool {
+	const minBits = 48
+	n := textLimit(c.Type)
+	switch rule.Kind {
+	case "keep", "null":
+		return true
+	case "hash":
+		if bits := integerBits(c.Type); bits > 0 {
+			return bits >= minBits
+		}
+		return n == 0 || n*4 >= minBits
+	case "email":
+		return n == 0 || (n-len(fakeDomain))*4 >= minBits
+	}
+
+	return false
+}
+
+var integerType = regexp.MustCompile(`(?i)^(tinyint|smallint|mediumint|int|integer|bigint)\b`)
+
+// integerBits 整数列可以存放的非负数的位数，有符号的列少一位，不是整数时为0
+func integerBits(typ string) uint {
+	match := integerType.FindStringSubmatch(typ)
+	if match == nil {
+		return 0
+	}
+
+	bits := map[string]uint{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "integer": 32, "bigint": 64}[strings.ToLower(match[1])]
+	if !strings.Contains(strings.ToLower(typ), "unsigned") {
+		bits--
+	}
+
+	return bits
+}
+
+var (
+	textType   = regexp.MustCompile(`(?i)^(var)?char\b|text\b`)
+	textLength = regexp.MustCompile(`(?i)^(?:var)?char\((\d+)\)`)
+)
+
+func isTextType(typ string) bool {
+	return textType.MatchString(typ)
+}
+
+// 生成假名字使用的字
+var (
+	fakeSurnames  = []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭"}
+	fakeGiven     = []string{"伟", "芳", "娜", "敏", "静", "磊", "洋", "勇", "艳", "杰", "娟", "涛", "明", "超", "霞", "平"}
+	fakeFirstName = []string{"Alex", "Sam", "Jamie", "Taylor", "Jordan", "Casey", "Riley", "Morgan", "Avery", "Quinn", "Drew", "Parker", "Reese", "Rowan", "Sage", "Skyler"}
+	fakeLastName  = []string{"Smith", "Brown", "Lee", "Walker", "Hall", "Young", "King", "Wright", "Scott", "Green", "Baker", "Adams", "Nelson", "Hill", "Moore", "Clark"}
+)
+
+const fakeDomain = "@example.com"
+
+// masker 按规则生成脱敏后的SQL字面量，结果由盐及原值决定
+type masker struct {
+	salt string
+}
+
+func (m masker) literal(rule maskRule, c *Column, value sql.RawBytes, typ string) string {
+	switch rule.Kind {
+	case "null":
+		return "NULL"
+	case "fixed":
+		return quoteString(rule.Value)
+	case "keep":
+		return sqlValue(value, typ)
+	}
+	if value == nil {
+		return "NULL"
+	}
+
+	sum := sha256.Sum256([]byte(m.salt + "\x00" + string(value)))
+	digest := hex.EncodeToString(sum[:])
+
+	switch rule.Kind {
+	case "email":
+		// 列较短时缩短用户名部分，保证域名完整
+		local := "user_" + digest[:12]
+		if n := textLimit(c.Type); n > 0 && len(local)+len(fakeDomain) > n && n > len(fakeDomain) {
+			local = digest[:n-len(fakeDomain)]
+		}
+		return quoteString(fit(local+fakeDomain, c.Type))
+	case "name":
+		if strings.HasPrefix(c.Charset, "utf8") || strings.HasPrefix(c.Charset, "gb") {
+			return quoteString(fit(fakeSurnames[sum[0]%16]+fakeGiven[sum[1]%16]+fakeGiven[sum[2]%16], c.Type))
+		}
+		return quoteString(fit(fakeFirstName[sum[0]%16]+" "+fakeLastName[sum[1]%16], c.Type))
+	}
+
+	// hash：整数列取摘要的高位，不超过列的范围；validate 保证其他列为文本
+	if bits := integerBits(c.Type); bits > 0 {
+		return strconv.FormatUint(binary.BigEndian.Uint64(sum[:8])>>(64-bits), 10)
+	}
+
+	return quoteString(fit(digest, c.Type))
+}
+
+// textLimit char/varchar 列的长度，其他类型为0
+func textLimit(typ string) int {
+	match := textLength.FindStringSubmatch(typ)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+
+	return n
+}
+
+// fit 截断到 char/varchar 列的长度
+func fit(value string, typ string) string {
+	if n := textLimit(typ); n > 0 {
+		if runes := []rune(value); len(runes) > n {
+			return string(runes[:n])
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,359 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var migrate = &cobra.Command{
+	Use:   "migrate",
+	Short: "执行版本化的数据库迁移",
+	Long: color.Success.Render("\r\n按版本号执行目录中的迁移文件，文件名格式为 <版本>_<名称>.up.sql 及 <版本>_<名称>.down.sql，\r\n" +
+		"已执行的版本记录在 schema_migrations 表中，通过 GET_LOCK 防止同时执行"),
+}
+
+var migrateUp = &cobra.Command{
+	Use:   "up",
+	Short: "执行未执行的迁移",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _ := cmd.Flags().GetInt64("to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		ctx := context.Background()
+		state, err := openMigrations(cmd, ctx, !dryRun)
+		if err != nil {
+			return err
+		}
+		defer state.close()
+
+		if err := state.verify(); err != nil {
+			return err
+		}
+
+		var pending []migrationFile
+		for _, m := range state.files {
+			if _, ok := state.applied[m.Version]; ok {
+				continue
+			}
+			if cmd.Flags().Changed("to") && m.Version > target {
+				break
+			}
+			pending = append(pending, m)
+		}
+		if len(pending) == 0 {
+			color.Infoln("没有需要执行的迁移")
+			return nil
+		}
+		if latest := state.latest(); latest > pending[0].Version {
+			color.Warnln(fmt.Sprintf("版本 %d 早于已执行的最新版本 %d，将按顺序补充执行", pending[0].Version, latest))
+		}
+
+		for _, m := range pending {
+			if dryRun {
+				if err := printMigration(m.Version, m.Name, m.Up); err != nil {
+					return err
+				}
+				continue
+			}
+
+			checksum, err := m.Checksum()
+			if err != nil {
+				return err
+			}
+			start := time.Now()
+			color.Infoln(fmt.Sprintf("执行 %d_%s", m.Version, m.Name))
+			if err := runFile(ctx, state.conn, m.Up); err != nil {
+				return fmt.Errorf("迁移 %d_%s 失败，之前的语句可能已经生效：%s", m.Version, m.Name, err)
+			}
+			if _, err := state.conn.ExecContext(ctx, "INSERT INTO "+quoteIdent(migrationsTable)+" (version, name, checksum) VALUES (?, ?, ?)", m.Version, m.Name, checksum); err != nil {
+				return err
+			}
+			color.Gray.Println(fmt.Sprintf("（%s）", time.Since(start).Round(time.Millisecond)))
+		}
+
+		if !dryRun {
+			color.Infoln(fmt.Sprintf("已执行 %d 个迁移", len(pending)))
+		}
+
+		return nil
+	},
+}
+
+var migrateDown = &cobra.Command{
+	Use:   "down",
+	Short: "回滚已执行的迁移",
+	Long:  color.Success.Render("\r\n回滚已执行的迁移，默认回滚最近的一个，--to 回滚所有大于该版本的迁移"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _ := cmd.Flags().GetInt64("to")
+		steps, _ := cmd.Flags().GetInt("steps")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		ctx := context.Background()
+		state, err := openMigrations(cmd, ctx, !dryRun)
+		if err != nil {
+			return err
+		}
+		defer state.close()
+
+		versions := make([]int64, 0, len(state.applied))
+		for version := range state.applied {
+			if cmd.Flags().Changed("to") && version <= target {
+				continue
+			}
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if !cmd.Flags().Changed("to") && steps < len(versions) {
+			versions = versions[:steps]
+		}
+		if len(versions) == 0 {
+			color.Infoln("没有需要回滚的迁移")
+			return nil
+		}
+
+		files := map[int64]migrationFile{}
+		for _, m := range state.files {
+			files[m.Version] = m
+		}
+		for _, version := range versions {
+			if m, ok := files[version]; !ok || m.Down == "" {
+				return fmt.Errorf("版本 %d_%s 没有 .down.sql 文件，无法回滚", version, state.applied[version].Name)
+			}
+		}
+
+		for _, version := range versions {
+			m := files[version]
+			if dryRun {
+				if err := printMigration(m.Version, m.Name, m.Down); err != nil {
+					return err
+				}
+				continue
+			}
+
+			start := time.Now()
+			color.Infoln(fmt.Sprintf("回滚 %d_%s", m.Version, m.Name))
+			if err := runFile(ctx, state.conn, m.Down); err != nil {
+				return fmt.Errorf("回滚 %d_%s 失败，之前的语句可能已经生效：%s", m.Version, m.Name, err)
+			}
+			if _, err := state.conn.ExecContext(ctx, "DELETE FROM "+quoteIdent(migrationsTable)+" WHERE version = ?", m.Version); err != nil {
+				return err
+			}
+			color.Gray.Println(fmt.Sprintf("（%s）", time.Since(start).Round(time.Millisecond)))
+		}
+
+		if !dryRun {
+			color.Infoln(fmt.Sprintf("已回滚 %d 个迁移", len(versions)))
+		}
+
+		return nil
+	},
+}
+
+var migrateStatus = &cobra.Command{
+	Use:   "status",
+	Short: "查看迁移的执行状态",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		state, err := openMigrations(cmd, ctx, false)
+		if err != nil {
+			return err
+		}
+		defer state.close()
+
+		result := &resultSet{
+			Columns: []string{"版本", "名称", "状态", "执行时间"},
+			Numeric: []bool{true, false, false, false},
+		}
+		seen := map[int64]bool{}
+		for _, m := range state.files {
+			seen[m.Version] = true
+			status, appliedAt := "待执行", ""
+			if applied, ok := state.applied[m.Version]; ok {
+				status, appliedAt = "已执行", applied.AppliedAt.Format("2006-01-02 15:04:05")
+				if checksum, err := m.Checksum(); err == nil && checksum != applied.Checksum {
+					status = "已执行，文件已修改"
+				}
+			}
+			result.Rows = append(result.Rows, cells(strconv.FormatInt(m.Version, 10), m.Name, status, appliedAt))
+		}
+		for _, applied := range state.applied {
+			if !seen[applied.Version] {
+				result.Rows = append(result.Rows, cells(strconv.FormatInt(applied.Version, 10), applied.Name, "已执行，文件缺失", applied.AppliedAt.Format("2006-01-02 15:04:05")))
+			}
+		}
+		sort.SliceStable(result.Rows, func(i, j int) bool {
+			a, _ := strconv.ParseInt(*result.Rows[i][0], 10, 64)
+			b, _ := strconv.ParseInt(*result.Rows[j][0], 10, 64)
+			return a < b
+		})
+
+		return result.writeTable(os.Stdout)
+	},
+}
+
+var migrateCreate = &cobra.Command{
+	Use:   "create <name>",
+	Short: "创建一对新的迁移文件",
+	Args:  cobra.ExactArgs(1),
+	// 只生成文件，不需要连接数据库
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		sequential, _ := cmd.Flags().GetBool("seq")
+
+		slug := migrationSlug(args[0])
+		if slug == "" {
+			return errors.New("迁移名称只能包含字母、数字及下划线")
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		migrations, err := loadMigrations(dir)
+		if err != nil {
+			return err
+		}
+
+		version := nextVersion(migrations, sequential)
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, version+"_"+slug+"."+direction+".sql")
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(file, "-- %s %s\n", slug, direction)
+			file.Close()
+			color.Infoln("已创建：" + path)
+		}
+
+		return nil
+	},
+}
+
+// migrationState 迁移文件及数据库中已执行的版本
+type migrationState struct {
+	conn    *sql.Conn
+	files   []migrationFile
+	applied map[int64]appliedMigration
+	close   func()
+}
+
+// openMigrations 读取迁移文件及已执行的版本，write为true时加锁并确保记录表存在
+func openMigrations(cmd *cobra.Command, ctx context.Context, write bool) (*migrationState, error) {
+	dir, _ := cmd.Flags().GetString("dir")
+	name, _ := cmd.Flags().GetString("db")
+	lockTimeout, _ := cmd.Flags().GetDuration("lock-timeout")
+
+	files, err := loadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// applied_at 需要解析为 time.Time，不能使用不解析时间的 session
+	conn, closeAll, err := openSession(cmd, ctx, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	state := &migrationState{conn: conn, files: files, close: closeAll}
+
+	if write {
+		release, err := acquireLock(ctx, conn, lockTimeout)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		state.close = func() {
+			release()
+			closeAll()
+		}
+		if err := ensureMigrationsTable(ctx, conn); err != nil {
+			state.close()
+			return nil, err
+		}
+	}
+
+	state.applied, err = loadApplied(ctx, conn)
+	if err != nil {
+		state.close()
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// verify 检查已执行的迁移文件是否被修改
+func (s *migrationState) verify() error {
+	var modified []string
+	for _, m := range s.files {
+		applied, ok := s.applied[m.Version]
+		if !ok {
+			continue
+		}
+		checksum, err := m.Checksum()
+		if err != nil {
+			return err
+		}
+		if checksum != applied.Checksum {
+			modified = append(modified, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+	}
+	if len(modified) > 0 {
+		return errors.New("以下迁移执行后文件被修改，请新建迁移而不是修改已执行的文件：\r\n  " + strings.Join(modified, "\r\n  "))
+	}
+
+	return nil
+}
+
+// latest 已执行的最大版本
+func (s *migrationState) latest() int64 {
+	var latest int64
+	for version := range s.applied {
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return latest
+}
+
+// printMigration 预览时输出将要执行的语句
+func printMigration(version int64, name, path string) error {
+	statements, err := readStatements(path)
+	if err != nil {
+		return err
+	}
+
+	color.Infoln(fmt.Sprintf("-- %d_%s（%s）", version, name, filepath.Base(path)))
+	for _, statement := range statements {
+		fmt.Println(statement + ";")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func init() {
+	migrate.AddCommand(migrateUp)
+	migrate.AddCommand(migrateDown)
+	migrate.AddCommand(migrateStatus)
+	migrate.AddCommand(migrateCreate)
+	migrate.PersistentFlags().String("dir", "migrations", "迁移文件所在目录")
+	migrate.PersistentFlags().String("db", "", "数据库，默认使用地址中的库")
+	migrate.PersistentFlags().Duration("lock-timeout", 30*time.Second, "等待其他迁移释放锁的时间")
+	for _, c := range []*cobra.Command{migrateUp, migrateDown} {
+		c.Flags().Int64("to", 0, "目标版本")
+		c.Flags().Bool("dry-run", false, "只输出将要执行的语句")
+	}
+	migrateDown.Flags().Int("steps", 1, "回滚的迁移数量，指定 --to 时忽略")
+	migrateCreate.Flags().Bool("seq", false, "使用递增序号作为版本，默认使用时间戳")
+}
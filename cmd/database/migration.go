@@ -0,0 +1,224 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 迁移文件名：<版本>_<名称>.up.sql 及 <版本>_<名称>.down.sql
+var migrationPattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+const migrationsTable = "schema_migrations"
+
+type migrationFile struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum up文件内容的sha256，用于发现已执行后又被修改的迁移
+func (m migrationFile) Checksum() (string, error) {
+	content, err := os.ReadFile(m.Up)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(content)), nil
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// loadMigrations 读取目录中的迁移文件，按版本排序
+func loadMigrations(dir string) ([]migrationFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*migrationFile{}
+	for _, entry := range entries {
+		match := migrationPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("版本号不正确：%s", entry.Name())
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &migrationFile{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("版本 %d 对应了多个迁移：%s、%s", version, m.Name, match[2])
+		}
+		path := filepath.Join(dir, entry.Name())
+		if match[3] == "up" {
+			m.Up = path
+		} else {
+			m.Down = path
+		}
+	}
+
+	migrations := make([]migrationFile, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("版本 %d 缺少 .up.sql 文件", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ensureMigrationsTable 创建记录已执行版本的表
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+quoteIdent(migrationsTable)+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	) DEFAULT CHARSET=utf8mb4`)
+
+	return err
+}
+
+// loadApplied 读取已执行的版本，表不存在时返回空
+func loadApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	applied := map[int64]appliedMigration{}
+
+	var found string
+	err := conn.QueryRowContext(ctx, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", migrationsTable).Scan(&found)
+	if err == sql.ErrNoRows {
+		return applied, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+quoteIdent(migrationsTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := appliedMigration{}
+		if err := rows.Scan(&m.Version, &m.Name, &m.Checksum, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[m.Version] = m
+	}
+
+	return applied, rows.Err()
+}
+
+// acquireLock 通过 GET_LOCK 防止同一个库同时执行多个迁移，锁随连接释放
+func acquireLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(), error) {
+	var database sql.NullString
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&database); err != nil {
+		return nil, err
+	}
+	if !database.Valid {
+		return nil, errors.New("请通过 --db 或地址指定数据库")
+	}
+
+	// 锁名最长64个字符
+	name := "jarvis_migrate:" + database.String
+	if len(name) > 64 {
+		name = fmt.Sprintf("jarvis_migrate:%x", sha256.Sum256([]byte(database.String)))[:64]
+	}
+
+	var result sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(timeout.Seconds())).Scan(&result); err != nil {
+		return nil, err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		return nil, errors.New("另一个迁移正在执行，等待锁超时")
+	}
+
+	return func() {
+		conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+	}, nil
+}
+
+// runFile 逐条执行迁移文件中的语句
+func runFile(ctx context.Context, conn *sql.Conn, path string) error {
+	statements, err := readStatements(path)
+	if err != nil {
+		return err
+	}
+
+	for i, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("第 %d 条语句执行失败：%s\r\n%s", i+1, err, abbreviate(statement, 200))
+		}
+	}
+
+	return nil
+}
+
+// readStatements 拆分文件中的语句
+func readStatements(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var statements []string
+	s := newSplitter(file)
+	for {
+		statement, err := s.next()
+		if err == io.EOF {
+			return statements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+}
+
+// nextVersion 新迁移的版本号：默认使用时间戳，sequential时在最大版本上加一并保持位数
+func nextVersion(migrations []migrationFile, sequential bool) string {
+	if !sequential {
+		return time.Now().Format("20060102150405")
+	}
+
+	var max int64
+	for _, m := range migrations {
+		if m.Version > max {
+			max = m.Version
+		}
+	}
+
+	return fmt.Sprintf("%04d", max+1)
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// migrationSlug 将名称转换为文件名中使用的小写下划线形式
+func migrationSlug(name string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
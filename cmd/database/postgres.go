@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresDialect PostgreSQL，账号即可以登录的角色，连接时默认使用 postgres 库
+type postgresDialect struct{}
+
+func (postgresDialect) DefaultPort() int {
+	return 5432
+}
+
+// Open 转换为 lib/pq 的 key=value 连接串，TLS模式对应 sslmode
+func (postgresDialect) Open(c Config) (*sql.DB, error) {
+	database := c.Database
+	if database == "" {
+		database = "postgres"
+	}
+
+	host := c.Host
+	if c.Socket != "" {
+		// lib/pq 以socket所在的目录作为host
+		host = c.Socket
+		if i := strings.LastIndex(host, "/.s.PGSQL."); i >= 0 {
+			host = host[:i]
+		}
+	}
+
+	mode := ""
+	switch c.TLS {
+	case "", "false":
+		mode = "disable"
+		if c.CA != "" {
+			mode = "verify-full"
+		}
+	case "true":
+		mode = "verify-full"
+	case "skip-verify":
+		mode = "require"
+	default:
+		return nil, fmt.Errorf("PostgreSQL 不支持的TLS模式：%s，可选 true、false、skip-verify", c.TLS)
+	}
+
+	params := map[string]string{
+		"host":            host,
+		"port":            strconv.Itoa(c.Port),
+		"user":            c.User,
+		"password":        c.Password,
+		"dbname":          database,
+		"sslmode":         mode,
+		"sslrootcert":     c.CA,
+		"sslcert":         c.Cert,
+		"sslkey":          c.Key,
+		"connect_timeout": strconv.Itoa(int(c.Timeout.Seconds())),
+	}
+	var dsn []string
+	for _, name := range []string{"host", "port", "user", "password", "dbname", "sslmode", "sslrootcert", "sslcert", "sslkey", "connect_timeout"} {
+		if params[name] != "" && params[name] != "0" {
+			dsn = append(dsn, name+"="+pgConnValue(params[name]))
+		}
+	}
+
+	db, err := sql.Open("postgres", strings.Join(dsn, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ping(db, c); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// pgConnValue 连接串中的值，使用单引号并转义反斜杠及单引号
+func pgConnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// QuoteIdent 双引号，内部的双引号写两次
+func (postgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteString 单引号写两次，standard_conforming_strings 开启时反斜杠不转义，
+// 使用前由 checkStandardStrings 确认
+func (postgresDialect) QuoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// checkStandardStrings 关闭 standard_conforming_strings 时单引号内的反斜杠会转义，
+// QuoteString 生成的字面量与原值不同，值中包含反斜杠时拒绝执行
+func checkStandardStrings(db queryer, values ...string) error {
+	found := false
+	for _, value := range values {
+		if strings.Contains(value, `\`) {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var setting string
+	if err := db.QueryRow("SHOW standard_conforming_strings").Scan(&setting); err != nil {
+		return err
+	}
+	if setting != "on" {
+		return errors.New("服务器关闭了 standard_conforming_strings，密码及编码中不能包含反斜杠")
+	}
+
+	return nil
+}
+
+func (postgresDialect) ListDatabases(db *sql.DB) ([]string, error) {
+	var names []string
+	err := eachRow(db, "SELECT datname FROM pg_database WHERE NOT datistemplate ORDER BY datname", nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+
+	return names, err
+}
+
+// pgLocale 编码及区域设置名称，如 UTF8、en_US.UTF-8、zh_CN.utf8@euro
+var pgLocale = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// CreateDatabase 字符集对应 ENCODING，排序规则对应 LC_COLLATE，
+// 与 template1 不同的编码或排序规则需要基于 template0 创建
+func (d postgresDialect) CreateDatabase(db *sql.DB, c Config, name string, opts createOptions) error {
+	for _, value := range []string{opts.Charset, opts.Collation} {
+		if value != "" && !pgLocale.MatchString(value) {
+			return fmt.Errorf("编码或排序规则不正确：%s", value)
+		}
+	}
+
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if err := checkStandardStrings(db, opts.Charset, opts.Collation); err != nil {
+		return err
+	}
+
+	query := "CREATE DATABASE " + d.QuoteIdent(name)
+	if opts.Charset != "" {
+		query += " ENCODING " + d.QuoteString(opts.Charset)
+	}
+	if opts.Collation != "" {
+		query += " LC_COLLATE " + d.QuoteString(opts.Collation)
+	}
+	if opts.Charset != "" || opts.Collation != "" {
+		query += " TEMPLATE template0"
+	}
+	_, err := db.Exec(query)
+
+	return err
+}
+
+// TableStats 统计当前库中各个 schema 的表，schema 不为空时只统计该 schema
+func (postgresDialect) TableStats(db *sql.DB, schema string) ([]tableStat, error) {
+	filter := "n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg\\_toast%'"
+	params := []interface{}{}
+	if schema != "" {
+		filter = "n.nspname = $1"
+		params = append(params, schema)
+	}
+
+	var tables []tableStat
+	err := eachRow(db, `SELECT n.nspname, c.relname, GREATEST(c.reltuples, 0)::bigint, pg_table_size(c.oid), pg_indexes_size(c.oid),
+			EXISTS (SELECT 1 FROM pg_constraint p WHERE p.conrelid = c.oid AND p.contype = 'p')
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND `+filter, params, func(rows *sql.Rows) error {
+		t := tableStat{}
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Rows, &t.Data, &t.Index, &t.PrimaryKey); err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	})
+
+	return tables, err
+}
+
+// CreateUser PostgreSQL 的账号不区分来源主机，来源由 pg_hba.conf 控制
+func (d postgresDialect) CreateUser(db *sql.DB, name, host, password string) error {
+	if err := checkStandardStrings(db, password); err != nil {
+		return err
+	}
+	_, err := db.Exec("CREATE ROLE " + d.QuoteIdent(name) + " LOGIN PASSWORD " + d.QuoteString(password))
+	return err
+}
+
+func (d postgresDialect) DropUser(db *sql.DB, name, host string) error {
+	_, err := db.Exec("DROP ROLE " + d.QuoteIdent(name))
+	return err
+}
+
+func (d postgresDialect) SetPassword(db *sql.DB, name, host, password string) error {
+	if err := checkStandardStrings(db, password); err != nil {
+		return err
+	}
+	_, err := db.Exec("ALTER ROLE " + d.QuoteIdent(name) + " PASSWORD " + d.QuoteString(password))
+	return err
+}
+
+func (postgresDialect) ListUsers(db *sql.DB) ([]string, error) {
+	var users []string
+	err := eachRow(db, "SELECT rolname FROM pg_roles WHERE rolcanlogin ORDER BY rolname", nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		users = append(users, name)
+		return nil
+	})
+
+	return users, err
+}
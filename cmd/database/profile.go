@@ -0,0 +1,157 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// Profile 保存的连接配置，地址中不包含密码
+type Profile struct {
+	URL      string `json:"url"`
+	Password string `json:"password,omitempty"`
+}
+
+var profile = &cobra.Command{
+	Use:   "profile",
+	Short: "管理保存的连接配置",
+	Long:  color.Success.Render("\r\n管理保存的连接配置，通过 --profile <名称> 使用，文件仅当前用户可读"),
+	// 只读写本地文件，不需要连接数据库
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+var profileSave = &cobra.Command{
+	Use:   "save <name>",
+	Short: "将当前的连接参数保存为配置",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := resolveConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		profiles, err := loadProfiles()
+		if err != nil {
+			return err
+		}
+		profiles[args[0]] = Profile{URL: cfg.String(), Password: cfg.Password}
+		if err := saveProfiles(profiles); err != nil {
+			return err
+		}
+
+		color.Infoln("已保存连接配置 " + args[0] + "：" + cfg.String())
+
+		return nil
+	},
+}
+
+var profileList = &cobra.Command{
+	Use:   "list",
+	Short: "列出保存的连接配置",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := loadProfiles()
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			color.Println("  " + name + "  " + profiles[name].URL + "  密码：" + maskPassword(profiles[name].Password))
+		}
+
+		return nil
+	},
+}
+
+var profileDelete = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "删除保存的连接配置",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := loadProfiles()
+		if err != nil {
+			return err
+		}
+		if _, ok := profiles[args[0]]; !ok {
+			return errors.New("找不到连接配置：" + args[0])
+		}
+		delete(profiles, args[0])
+
+		return saveProfiles(profiles)
+	},
+}
+
+// profilesPath 连接配置文件位于 ~/.jarvis/database/profiles.json
+func profilesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".jarvis", "database", "profiles.json"), nil
+}
+
+func loadProfiles() (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+
+	path, err := profilesPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, json.Unmarshal(content, &profiles)
+}
+
+func saveProfiles(profiles map[string]Profile) error {
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0600)
+}
+
+// findProfile 按名称读取连接配置
+func findProfile(name string) (Profile, error) {
+	profiles, err := loadProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, errors.New("找不到连接配置：" + name)
+	}
+
+	return p, nil
+}
+
+func init() {
+	profile.AddCommand(profileSave)
+	profile.AddCommand(profileList)
+	profile.AddCommand(profileDelete)
+}
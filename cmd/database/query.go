@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var query = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "执行一条SQL语句",
+	Long:  color.Success.Render("\r\n执行一条SQL语句，查询结果可输出为表格、CSV、JSON或Markdown"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("db")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		if output != "" && !cmd.Flags().Changed("format") {
+			format = formatFor(output)
+		}
+
+		ctx := context.Background()
+		conn, closeAll, err := session(cmd, ctx, name)
+		if err != nil {
+			return err
+		}
+		defer closeAll()
+
+		result, affected, err := execute(ctx, conn, args[0])
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			color.Infoln(fmt.Sprintf("影响 %d 行", affected))
+			return nil
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+		if err := result.write(w, format); err != nil {
+			return err
+		}
+		if output != "" {
+			color.Infoln(fmt.Sprintf("已将 %d 行保存到：%s", len(result.Rows), output))
+		}
+
+		return nil
+	},
+}
+
+// session 打开一个独立的连接，USE、SET 等语句在同一个连接中持续生效。
+// 不解析时间，DATETIME 及零值日期按服务器返回的原样输出
+func session(cmd *cobra.Command, ctx context.Context, name string) (*sql.Conn, func(), error) {
+	return openSession(cmd, ctx, name, func(c *mysql.Config) { c.ParseTime = false })
+}
+
+// openSession 按 tweak 调整连接参数后打开一个独立的连接，name 不为空时切换到该数据库
+func openSession(cmd *cobra.Command, ctx context.Context, name string, tweak func(*mysql.Config)) (*sql.Conn, func(), error) {
+	cfg, err := resolveConfig(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := openWith(cfg, tweak)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	closeAll := func() {
+		conn.Close()
+		db.Close()
+	}
+
+	if name != "" {
+		if _, err := conn.ExecContext(ctx, "USE "+quoteIdent(name)); err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+	}
+
+	return conn, closeAll, nil
+}
+
+// execute 执行一条语句，读语句返回结果集，写语句返回影响的行数
+func execute(ctx context.Context, conn *sql.Conn, statement string) (*resultSet, int64, error) {
+	if isReadStatement(statement) {
+		rows, err := conn.QueryContext(ctx, statement)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer rows.Close()
+
+		result, err := fetch(rows)
+		return result, 0, err
+	}
+
+	res, err := conn.ExecContext(ctx, statement)
+	if err != nil {
+		return nil, 0, err
+	}
+	affected, _ := res.RowsAffected()
+
+	return nil, affected, nil
+}
+
+// isReadStatement 判断是否为只读取数据的语句，WITH 按公用表表达式之后的语句判断
+func isReadStatement(statement string) bool {
+	keyword := firstKeyword(statement)
+	if keyword == "WITH" {
+		keyword = withKeyword(statement)
+	}
+
+	switch keyword {
+	case "SELECT", "SHOW", "DESC", "DESCRIBE", "EXPLAIN", "VALUES", "TABLE", "HELP":
+		return true
+	}
+
+	return false
+}
+
+// withKeyword WITH 语句中公用表表达式之后的主语句关键字，如 WITH t AS (...) DELETE 为 DELETE。
+// 只看括号外的单词，右括号之后不是 AS 或逗号的单词即为主语句
+func withKeyword(statement string) string {
+	depth := 0
+	var quote rune
+	afterParen := false
+	runes := []rune(statement)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && quote != '`' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				afterParen = true
+			}
+		case depth > 0:
+		case r == ',':
+			afterParen = false
+		case afterParen && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'):
+			word := firstKeyword(string(runes[i:]))
+			if word != "AS" {
+				return word
+			}
+			afterParen = false
+		}
+	}
+
+	return ""
+}
+
+// firstKeyword 跳过空白、注释及左括号后的第一个关键字
+func firstKeyword(statement string) string {
+	s := statement
+	for {
+		s = strings.TrimLeft(s, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(s, "--") || strings.HasPrefix(s, "#"):
+			if i := strings.Index(s, "\n"); i >= 0 {
+				s = s[i+1:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(s, "/*") && !strings.HasPrefix(s, "/*!"):
+			if i := strings.Index(s, "*/"); i >= 0 {
+				s = s[i+2:]
+				continue
+			}
+			return ""
+		}
+		break
+	}
+
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if end >= 0 {
+		s = s[:end]
+	}
+
+	return strings.ToUpper(s)
+}
+
+func init() {
+	query.Flags().String("db", "", "默认数据库")
+	query.Flags().StringP("format", "f", "table", "输出格式：table、csv、json、markdown")
+	query.Flags().StringP("output", "o", "", "保存到文件，默认按扩展名选择格式")
+}
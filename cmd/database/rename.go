@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// queryer *sql.DB 与 *sql.Tx 共有的查询方法
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+var rename = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "重命名数据库",
+	Long:  color.Success.Render("\r\n重命名数据库：按原库的字符集创建新库，将所有表移动到新库后删除空的原库。\r\n视图、存储过程、事件及账号权限不会移动"),
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to := args[0], args[1]
+		for _, name := range args {
+			if err := validateIdent(name); err != nil {
+				return err
+			}
+			if systemSchemas[strings.ToLower(name)] {
+				return errors.New("不能重命名系统库：" + name)
+			}
+		}
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		var charset, collation string
+		err = db.QueryRow("SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", from).Scan(&charset, &collation)
+		if err == sql.ErrNoRows {
+			return errors.New("数据库不存在：" + from)
+		}
+		if err != nil {
+			return err
+		}
+		if schemaExists(db, to) {
+			return errors.New("数据库已存在：" + to)
+		}
+
+		tables, err := names(db, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", from)
+		if err != nil {
+			return err
+		}
+		// 带触发器的表不能跨库重命名
+		triggers, err := names(db, "SELECT TRIGGER_NAME FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ?", from)
+		if err != nil {
+			return err
+		}
+		if len(triggers) > 0 {
+			return fmt.Errorf("数据库中有触发器，请先删除后再重命名：%s", strings.Join(triggers, ", "))
+		}
+
+		if _, err := db.Exec("CREATE DATABASE " + quoteIdent(to) + " CHARACTER SET " + charset + " COLLATE " + collation); err != nil {
+			return fmt.Errorf("创建数据库失败：%s", err)
+		}
+		color.Infoln("已创建数据库：" + to)
+
+		if len(tables) > 0 {
+			// 一条 RENAME TABLE 语句移动所有表，失败时不会只移动一部分
+			pairs := make([]string, len(tables))
+			for i, table := range tables {
+				pairs[i] = quoteIdent(from) + "." + quoteIdent(table) + " TO " + quoteIdent(to) + "." + quoteIdent(table)
+			}
+			if _, err := db.Exec("RENAME TABLE " + strings.Join(pairs, ", ")); err != nil {
+				db.Exec("DROP DATABASE " + quoteIdent(to))
+				return fmt.Errorf("移动表失败：%s", err)
+			}
+		}
+		color.Infoln(fmt.Sprintf("已移动 %d 张表", len(tables)))
+
+		left, err := names(db, `SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?
+			UNION ALL SELECT ROUTINE_NAME FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ?
+			UNION ALL SELECT EVENT_NAME FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ?`, from, from, from)
+		if err != nil {
+			return err
+		}
+		if len(left) > 0 {
+			color.Warnln("原库中还有视图、存储过程或事件，未删除原库：" + strings.Join(left, ", "))
+		} else if _, err := db.Exec("DROP DATABASE " + quoteIdent(from)); err != nil {
+			return fmt.Errorf("删除原库失败：%s", err)
+		} else {
+			color.Infoln("已删除空的原库：" + from)
+		}
+
+		color.Warnln("账号权限不会随之移动，请用 database grant 为新库重新授权")
+
+		return nil
+	},
+}
+
+// names 执行返回单列字符串的查询
+func names(db queryer, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var restore = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "导入SQL文件",
+	Long:  color.Success.Render("\r\n逐条执行 .sql 或 .sql.gz 文件中的语句并显示进度，支持 DELIMITER"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("db")
+		createDb, _ := cmd.Flags().GetBool("create")
+		keepGoing, _ := cmd.Flags().GetBool("continue-on-error")
+
+		cfg, err := resolveConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = cfg.Database
+		}
+		if name == "" {
+			return errors.New("请通过 --db 指定导入的数据库")
+		}
+		if err := validateIdent(name); err != nil {
+			return err
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+
+		counter := &countingReader{r: file}
+		reader, err := openDump(counter)
+		if err != nil {
+			return err
+		}
+
+		db, err := openWith(cfg, nil)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if createDb {
+			if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS " + quoteIdent(name)); err != nil {
+				return fmt.Errorf("创建数据库失败：%s", err)
+			}
+		}
+
+		// 同一个连接中执行，SET 语句对之后的语句生效
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.ExecContext(ctx, "USE "+quoteIdent(name)); err != nil {
+			return fmt.Errorf("数据库不存在，可以使用 --create 创建：%s", err)
+		}
+
+		start := time.Now()
+		last := time.Time{}
+		executed, failed := 0, 0
+		progress := func(force bool) {
+			if !force && time.Since(last) < time.Second {
+				return
+			}
+			last = time.Now()
+			percent := 100.0
+			if info.Size() > 0 {
+				percent = float64(counter.n) * 100 / float64(info.Size())
+			}
+			fmt.Fprintf(os.Stderr, "\r已执行 %d 条语句，失败 %d 条，%.1f%%", executed, failed, percent)
+		}
+
+		splitter := newSplitter(reader)
+		for {
+			statement, err := splitter.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				failed++
+				if !keepGoing {
+					fmt.Fprintln(os.Stderr)
+					return fmt.Errorf("第 %d 行的语句执行失败：%s\r\n%s", splitter.line, err, abbreviate(statement, 200))
+				}
+				fmt.Fprintln(os.Stderr)
+				color.Warnln(fmt.Sprintf("第 %d 行的语句执行失败：%s", splitter.line, err))
+			}
+			executed++
+			progress(false)
+		}
+		progress(true)
+		fmt.Fprintln(os.Stderr)
+
+		color.Success.Println(fmt.Sprintf("导入完成：%d 条语句，失败 %d 条，用时 %s", executed, failed, time.Since(start).Round(time.Second)))
+		if failed > 0 {
+			return fmt.Errorf("有 %d 条语句执行失败", failed)
+		}
+
+		return nil
+	},
+}
+
+// countingReader 记录已读取的字节数，用于计算进度
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// openDump 按文件头判断是否为gzip压缩
+func openDump(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReaderSize(r, 1<<20)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+
+	return buffered, nil
+}
+
+// splitter 按分隔符拆分SQL语句，跳过引号及注释中的分隔符，/*! */ 条件注释保留在语句中
+type splitter struct {
+	r         *bufio.Reader
+	delimiter string
+	line      int
+}
+
+func newSplitter(r io.Reader) *splitter {
+	return &splitter{r: bufio.NewReader(r), delimiter: ";", line: 1}
+}
+
+// next 返回下一条非空语句，读完时返回 io.EOF
+func (s *splitter) next() (string, error) {
+	var b strings.Builder
+	var quote rune
+	atLineStart := true
+
+	for {
+		r, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			if statement := strings.TrimSpace(b.String()); statement != "" {
+				return statement, nil
+			}
+			return "", io.EOF
+		}
+		if err != nil {
+			return "", err
+		}
+		if r == '\n' {
+			s.line++
+		}
+
+		if quote != 0 {
+			b.WriteRune(r)
+			switch {
+			case r == '\\' && quote != '`':
+				if next, _, err := s.r.ReadRune(); err == nil {
+					if next == '\n' {
+						s.line++
+					}
+					b.WriteRune(next)
+				}
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		// 行首的 DELIMITER 命令只在客户端生效，不发送到服务器
+		if atLineStart && (r == 'D' || r == 'd') && strings.TrimSpace(b.String()) == "" {
+			s.r.UnreadRune()
+			if line, ok := s.delimiterCommand(); ok {
+				s.delimiter = line
+				b.Reset()
+				continue
+			}
+			s.r.ReadRune()
+		}
+		atLineStart = r == '\n'
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			b.WriteRune(r)
+		case r == '#' || (r == '-' && s.peekIs("- ") || r == '-' && s.peekIs("-\n")):
+			s.skipLine()
+			atLineStart = true
+			b.WriteRune('\n')
+		case r == '/' && s.peekIs("*") && !s.peekIs("*!"):
+			s.skipBlockComment()
+		default:
+			b.WriteRune(r)
+		}
+
+		if strings.HasSuffix(b.String(), s.delimiter) {
+			statement := strings.TrimSpace(strings.TrimSuffix(b.String(), s.delimiter))
+			b.Reset()
+			if statement != "" {
+				return statement, nil
+			}
+		}
+	}
+}
+
+// delimiterCommand 读取 DELIMITER 命令，不是该命令时不消耗输入
+func (s *splitter) delimiterCommand() (string, bool) {
+	const keyword = "DELIMITER "
+	peek, _ := s.r.Peek(len(keyword))
+	if !strings.EqualFold(string(peek), keyword) {
+		return "", false
+	}
+
+	line, _ := s.r.ReadString('\n')
+	s.line++
+	delimiter := strings.TrimSpace(line[len(keyword):])
+	if delimiter == "" {
+		return "", false
+	}
+
+	return delimiter, true
+}
+
+func (s *splitter) peekIs(prefix string) bool {
+	peek, _ := s.r.Peek(len(prefix))
+	return string(peek) == prefix
+}
+
+func (s *splitter) skipLine() {
+	if _, err := s.r.ReadString('\n'); err == nil {
+		s.line++
+	}
+}
+
+func (s *splitter) skipBlockComment() {
+	prev := rune(0)
+	for {
+		r, _, err := s.r.ReadRune()
+		if err != nil {
+			return
+		}
+		if r == '\n' {
+			s.line++
+		}
+		if prev == '*' && r == '/' {
+			return
+		}
+		prev = r
+	}
+}
+
+// abbreviate 截断过长的语句，用于错误信息
+func abbreviate(statement string, n int) string {
+	runes := []rune(statement)
+	if len(runes) <= n {
+		return statement
+	}
+
+	return string(runes[:n]) + "..."
+}
+
+func init() {
+	restore.Flags().String("db", "", "导入的数据库，默认使用连接地址中的数据库")
+	restore.Flags().Bool("create", false, "数据库不存在时创建")
+	restore.Flags().Bool("continue-on-error", false, "语句执行失败时继续执行后面的语句")
+}
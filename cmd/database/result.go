@@ -0,0 +1,238 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// resultSet 查询结果，NULL 保存为nil
+type resultSet struct {
+	Columns []string
+	Numeric []bool
+	Rows    [][]*string
+}
+
+// fetch 读取全部结果行
+func fetch(rows *sql.Rows) (*resultSet, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &resultSet{}
+	for _, typ := range types {
+		result.Columns = append(result.Columns, typ.Name())
+		result.Numeric = append(result.Numeric, isNumericType(typ.DatabaseTypeName()))
+	}
+
+	values := make([]sql.RawBytes, len(types))
+	dest := make([]interface{}, len(types))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]*string, len(values))
+		for i, value := range values {
+			if value != nil {
+				s := string(value)
+				row[i] = &s
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, rows.Err()
+}
+
+func isNumericType(typ string) bool {
+	switch typ {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "DECIMAL", "FLOAT", "DOUBLE", "YEAR":
+		return true
+	}
+
+	return false
+}
+
+// formatFor 根据文件扩展名选择导出格式
+func formatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	case ".md":
+		return "markdown"
+	}
+
+	return "table"
+}
+
+// write 按格式输出：table、csv、json、markdown
+func (r *resultSet) write(w io.Writer, format string) error {
+	switch format {
+	case "table", "":
+		return r.writeTable(w)
+	case "csv":
+		return r.writeCSV(w)
+	case "json":
+		return r.writeJSON(w)
+	case "markdown", "md":
+		return r.writeMarkdown(w)
+	}
+
+	return fmt.Errorf("不支持的格式：%s，可选 table、csv、json、markdown", format)
+}
+
+// cell 单元格展示的文本，换行及制表符替换为可见字符
+func (r *resultSet) cell(value *string) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	return strings.NewReplacer("\r\n", "↵", "\n", "↵", "\t", "→").Replace(*value)
+}
+
+// writeTable 按显示宽度对齐，中日韩文字占两列
+func (r *resultSet) writeTable(w io.Writer) error {
+	widths := make([]int, len(r.Columns))
+	for i, column := range r.Columns {
+		widths[i] = runewidth.StringWidth(column)
+	}
+	for _, row := range r.Rows {
+		for i, value := range row {
+			if width := runewidth.StringWidth(r.cell(value)); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	border := "+"
+	for _, width := range widths {
+		border += strings.Repeat("-", width+2) + "+"
+	}
+	line := func(cells []string) string {
+		s := "|"
+		for i, cell := range cells {
+			padding := strings.Repeat(" ", widths[i]-runewidth.StringWidth(cell))
+			if r.Numeric[i] {
+				s += " " + padding + cell + " |"
+			} else {
+				s += " " + cell + padding + " |"
+			}
+		}
+		return s
+	}
+
+	fmt.Fprintln(w, border)
+	fmt.Fprintln(w, line(r.Columns))
+	fmt.Fprintln(w, border)
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = r.cell(value)
+		}
+		fmt.Fprintln(w, line(cells))
+	}
+	if len(r.Rows) > 0 {
+		fmt.Fprintln(w, border)
+	}
+	_, err := fmt.Fprintf(w, "%d 行\n", len(r.Rows))
+
+	return err
+}
+
+func (r *resultSet) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = *value
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeJSON 输出对象数组，保持列的顺序，数值列输出为数字
+func (r *resultSet) writeJSON(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("[")
+	for n, row := range r.Rows {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, value := range row {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			key, _ := json.Marshal(r.Columns[i])
+			b.Write(key)
+			b.WriteString(": ")
+			switch {
+			case value == nil:
+				b.WriteString("null")
+			case r.Numeric[i] && json.Valid([]byte(*value)):
+				b.WriteString(*value)
+			default:
+				encoded, _ := json.Marshal(*value)
+				b.Write(encoded)
+			}
+		}
+		b.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *resultSet) writeMarkdown(w io.Writer) error {
+	escape := strings.NewReplacer("|", `\|`)
+
+	header := make([]string, len(r.Columns))
+	separator := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		header[i] = escape.Replace(column)
+		separator[i] = "---"
+		if r.Numeric[i] {
+			separator[i] = "---:"
+		}
+	}
+	fmt.Fprintln(w, "| "+strings.Join(header, " | ")+" |")
+	fmt.Fprintln(w, "| "+strings.Join(separator, " | ")+" |")
+
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = escape.Replace(r.cell(value))
+		}
+		if _, err := fmt.Fprintln(w, "| "+strings.Join(cells, " | ")+" |"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
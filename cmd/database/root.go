@@ -2,6 +2,10 @@ package database
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
 )
@@ -11,24 +15,21 @@ var DatabaseCmd = &cobra.Command{
 	Short: color.Blue.Render("数据库相关操作"),
 	Long:  color.Success.Render("\r\n数据库相关操作"),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		host, _ := cmd.Flags().GetString("host")
-		username, _ := cmd.Flags().GetString("username")
-		password, _ := cmd.Flags().GetString("password")
-
-		color.Infoln("地址：" + host)
-		color.Infoln("用户：" + username)
-		color.Infoln("密码：" + password)
-
-		if host == "" {
-			return errors.New(color.Error.Renderln("数据库地址") + "\r\n")
+		cfg, err := resolveConfig(cmd)
+		if err != nil {
+			return errors.New(color.Error.Renderln(err.Error()) + "\r\n")
 		}
 
-		if username == "" {
-			return errors.New(color.Error.Renderln("请输入用户名") + "\r\n")
+		// 输出到标准错误，查询结果及JSON输出到标准输出时不受影响
+		fmt.Fprintln(os.Stderr, color.Info.Render("地址："+cfg.String()))
+		if cfg.Driver == "sqlite" {
+			return nil
 		}
+		fmt.Fprintln(os.Stderr, color.Info.Render("用户："+cfg.User))
+		fmt.Fprintln(os.Stderr, color.Info.Render("密码："+maskPassword(cfg.Password)))
 
-		if password == "" {
-			return errors.New(color.Error.Renderln("请输入密码") + "\r\n")
+		if cfg.Host == "" && cfg.Socket == "" {
+			return errors.New(color.Error.Renderln("数据库地址") + "\r\n")
 		}
 
 		return nil
@@ -38,7 +39,35 @@ var DatabaseCmd = &cobra.Command{
 func init() {
 	DatabaseCmd.AddCommand(create)
 	DatabaseCmd.AddCommand(show)
-	DatabaseCmd.PersistentFlags().String("host", "http://127.0.0.1:3306", "数据库地址")
-	DatabaseCmd.PersistentFlags().StringP("username", "u", "root", "数据库用户名")
-	DatabaseCmd.PersistentFlags().StringP("password", "p", "root", "数据库密码")
+	DatabaseCmd.AddCommand(drop)
+	DatabaseCmd.AddCommand(rename)
+	DatabaseCmd.AddCommand(dump)
+	DatabaseCmd.AddCommand(restore)
+	DatabaseCmd.AddCommand(diff)
+	DatabaseCmd.AddCommand(query)
+	DatabaseCmd.AddCommand(console)
+	DatabaseCmd.AddCommand(stats)
+	DatabaseCmd.AddCommand(profile)
+	DatabaseCmd.AddCommand(user)
+	DatabaseCmd.AddCommand(grant)
+	DatabaseCmd.AddCommand(revoke)
+	DatabaseCmd.AddCommand(grants)
+	DatabaseCmd.AddCommand(migrate)
+	DatabaseCmd.AddCommand(top)
+	DatabaseCmd.AddCommand(kill)
+	DatabaseCmd.AddCommand(slowlog)
+	DatabaseCmd.AddCommand(copyData)
+	DatabaseCmd.AddCommand(erd)
+	DatabaseCmd.PersistentFlags().String("host", "127.0.0.1", "数据库地址，可以是 host、host:port 或 mysql://user@host:port/db?tls=true、postgres://user@host:port/db、sqlite://path/to/app.db")
+	DatabaseCmd.PersistentFlags().Int("port", 3306, "数据库端口")
+	DatabaseCmd.PersistentFlags().String("socket", "", "unix socket路径，指定后忽略地址和端口")
+	DatabaseCmd.PersistentFlags().StringP("username", "u", "", "数据库用户名，也可以通过 MYSQL_USER 或 ~/.my.cnf 指定")
+	DatabaseCmd.PersistentFlags().StringP("password", "p", "", "数据库密码，建议通过 MYSQL_PWD、~/.my.cnf、--profile 或终端输入提供")
+	DatabaseCmd.PersistentFlags().String("defaults-file", defaultOptionFile(), "mysql客户端配置文件，读取其中的 [client] 段")
+	DatabaseCmd.PersistentFlags().String("profile", "", "使用 database profile save 保存的连接配置")
+	DatabaseCmd.PersistentFlags().String("tls", "", "TLS模式：true、false、skip-verify、preferred")
+	DatabaseCmd.PersistentFlags().String("ssl-ca", "", "CA证书文件")
+	DatabaseCmd.PersistentFlags().String("ssl-cert", "", "客户端证书文件")
+	DatabaseCmd.PersistentFlags().String("ssl-key", "", "客户端私钥文件")
+	DatabaseCmd.PersistentFlags().Duration("timeout", 5*time.Second, "连接超时时间")
 }
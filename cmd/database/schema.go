@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema 从 information_schema 读取的库结构
+type Schema struct {
+	Name      string
+	Charset   string
+	Collation string
+	Tables    map[string]*Table
+}
+
+type Table struct {
+	Name          string
+	Engine        string
+	Collation     string
+	Comment       string
+	AutoIncrement sql.NullInt64
+	Columns       []*Column
+	Indexes       []*Index
+	ForeignKeys   []*ForeignKey
+}
+
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  sql.NullString
+	Extra    string
+	// Generation 生成列的表达式，普通列为空
+	Generation string
+	Charset    string
+	Collation  string
+	Comment    string
+}
+
+type Index struct {
+	Name   string
+	Unique bool
+	Type   string
+	// Columns 索引的列，前缀索引带长度如 name(10)，函数索引的表达式带括号如 (lower(`email`))
+	Columns []string
+}
+
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// TableNames 按名称排序的表名
+func (s *Schema) TableNames() []string {
+	names := make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Column 按名称查找列
+func (t *Table) Column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+
+	return nil
+}
+
+// PrimaryKey 主键包含的列
+func (t *Table) PrimaryKey() []string {
+	for _, index := range t.Indexes {
+		if index.Name == "PRIMARY" {
+			return index.Columns
+		}
+	}
+
+	return nil
+}
+
+// loadSchema 读取库中基础表的列、索引及外键，不包含视图
+func loadSchema(db *sql.DB, name string) (*Schema, error) {
+	schema := &Schema{Name: name, Tables: map[string]*Table{}}
+
+	err := db.QueryRow("SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", name).Scan(&schema.Charset, &schema.Collation)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("数据库不存在：%s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = eachRow(db, `SELECT TABLE_NAME, IFNULL(ENGINE, ''), IFNULL(TABLE_COLLATION, ''), IFNULL(TABLE_COMMENT, ''), AUTO_INCREMENT
+		FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'`, []interface{}{name}, func(rows *sql.Rows) error {
+		t := &Table{}
+		if err := rows.Scan(&t.Name, &t.Engine, &t.Collation, &t.Comment, &t.AutoIncrement); err != nil {
+			return err
+		}
+		schema.Tables[t.Name] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = eachRow(db, `SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA,
+		IFNULL(CHARACTER_SET_NAME, ''), IFNULL(COLLATION_NAME, ''), COLUMN_COMMENT, IFNULL(GENERATION_EXPRESSION, '')
+		FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, ORDINAL_POSITION`, []interface{}{name}, func(rows *sql.Rows) error {
+		var table, nullable string
+		c := &Column{}
+		if err := rows.Scan(&table, &c.Name, &c.Type, &nullable, &c.Default, &c.Extra, &c.Charset, &c.Collation, &c.Comment, &c.Generation); err != nil {
+			return err
+		}
+		c.Nullable = nullable == "YES"
+		// MySQL 8 用 DEFAULT_GENERATED 标记表达式默认值，不属于列定义
+		c.Extra = strings.TrimSpace(strings.Replace(c.Extra, "DEFAULT_GENERATED", "", 1))
+		// MySQL 8 返回的表达式中字符串的引号带有反斜杠，如 _utf8mb4\'a\'
+		c.Generation = strings.ReplaceAll(c.Generation, `\'`, `'`)
+		if t := schema.Tables[table]; t != nil {
+			t.Columns = append(t.Columns, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// MySQL 8.0.13 起支持函数索引，STATISTICS 才有 EXPRESSION 列
+	expression := "NULL"
+	var hasExpression int
+	err = db.QueryRow(`SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = 'information_schema' AND TABLE_NAME = 'STATISTICS' AND COLUMN_NAME = 'EXPRESSION'`).Scan(&hasExpression)
+	if err != nil {
+		return nil, err
+	}
+	if hasExpression > 0 {
+		expression = "EXPRESSION"
+	}
+
+	err = eachRow(db, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME, SUB_PART, INDEX_TYPE, `+expression+`
+		FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, []interface{}{name}, func(rows *sql.Rows) error {
+		var table, index, typ string
+		var column, expr sql.NullString
+		var nonUnique int
+		var subPart sql.NullInt64
+		if err := rows.Scan(&table, &index, &nonUnique, &column, &subPart, &typ, &expr); err != nil {
+			return err
+		}
+		t := schema.Tables[table]
+		if t == nil {
+			return nil
+		}
+		part := column.String
+		switch {
+		case !column.Valid:
+			// 函数索引没有列名，表达式的引号同样带有反斜杠
+			part = "(" + strings.ReplaceAll(expr.String, `\'`, `'`) + ")"
+		case subPart.Valid:
+			part += "(" + strconv.FormatInt(subPart.Int64, 10) + ")"
+		}
+		if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == index {
+			t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, part)
+			return nil
+		}
+		t.Indexes = append(t.Indexes, &Index{Name: index, Unique: nonUnique == 0, Type: typ, Columns: []string{part}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = eachRow(db, `SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`, []interface{}{name}, func(rows *sql.Rows) error {
+		var table, constraint, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&table, &constraint, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		t := schema.Tables[table]
+		if t == nil {
+			return nil
+		}
+		if n := len(t.ForeignKeys); n > 0 && t.ForeignKeys[n-1].Name == constraint {
+			fk := t.ForeignKeys[n-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			return nil
+		}
+		t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{
+			Name:       constraint,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnUpdate:   onUpdate,
+			OnDelete:   onDelete,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// eachRow 执行查询并对每一行调用fn
+func eachRow(db queryer, query string, args []interface{}, fn func(*sql.Rows) error) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
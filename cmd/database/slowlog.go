@@ -0,0 +1,352 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// slowEntry 慢日志中的一条记录
+type slowEntry struct {
+	DB           string
+	User         string
+	QueryTime    float64
+	LockTime     float64
+	RowsSent     int64
+	RowsExamined int64
+	Query        string
+}
+
+// slowDigest 同一指纹的统计
+type slowDigest struct {
+	Fingerprint  string  `json:"fingerprint"`
+	DB           string  `json:"db"`
+	Count        int     `json:"count"`
+	Total        float64 `json:"total_seconds"`
+	Average      float64 `json:"avg_seconds"`
+	P95          float64 `json:"p95_seconds"`
+	Max          float64 `json:"max_seconds"`
+	Lock         float64 `json:"lock_seconds"`
+	RowsExamined int64   `json:"rows_examined"`
+	RowsSent     int64   `json:"rows_sent"`
+	Sample       string  `json:"sample"`
+	times        []float64
+}
+
+var (
+	slowMetric   = regexp.MustCompile(`(\w+):\s+(\S+)`)
+	slowUserHost = regexp.MustCompile(`^# User@Host:\s+(\S+?)\[`)
+	slowUse      = regexp.MustCompile("(?i)^use\\s+`?([^`;\\s]+)`?;$")
+	slowSkip     = regexp.MustCompile(`(?i)^(SET\s+timestamp\s*=\s*\d+;|/\S+, Version: .*|Tcp port: .*|Time\s+Id\s+Command\s+Argument)$`)
+)
+
+var slowlog = &cobra.Command{
+	Use:   "slowlog <file>",
+	Short: "分析慢查询日志",
+	Long: color.Success.Render("\r\n离线分析慢查询日志，将语句中的常量替换为 ? 后按指纹汇总次数、总时间、平均及p95时间、扫描及返回行数，\r\n" +
+		"可以连接数据库对排名靠前的查询执行 EXPLAIN"),
+	Args: cobra.ExactArgs(1),
+	// 只在 --explain 时连接数据库
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		top, _ := cmd.Flags().GetInt("top")
+		order, _ := cmd.Flags().GetString("sort")
+		explain, _ := cmd.Flags().GetInt("explain")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r, err := openDump(file)
+		if err != nil {
+			return err
+		}
+
+		digests, total, err := digestSlowLog(r)
+		if err != nil {
+			return err
+		}
+		if err := sortDigests(digests, order); err != nil {
+			return err
+		}
+		if top > 0 && len(digests) > top {
+			digests = digests[:top]
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(digests); err != nil {
+				return err
+			}
+		} else {
+			printDigests(digests, total)
+		}
+
+		if explain > 0 {
+			if explain > len(digests) {
+				explain = len(digests)
+			}
+			return explainDigests(cmd, digests[:explain])
+		}
+
+		return nil
+	},
+}
+
+// digestSlowLog 解析慢日志并按指纹汇总，返回汇总结果及记录总数
+func digestSlowLog(r io.Reader) ([]*slowDigest, int, error) {
+	byFingerprint := map[string]*slowDigest{}
+	var digests []*slowDigest
+	count := 0
+
+	add := func(entry *slowEntry) {
+		query := strings.TrimSpace(entry.Query)
+		if query == "" {
+			return
+		}
+		count++
+
+		fingerprint := fingerprintQuery(query)
+		d := byFingerprint[fingerprint]
+		if d == nil {
+			d = &slowDigest{Fingerprint: fingerprint, DB: entry.DB}
+			byFingerprint[fingerprint] = d
+			digests = append(digests, d)
+		}
+		d.Count++
+		d.Total += entry.QueryTime
+		d.Lock += entry.LockTime
+		d.RowsExamined += entry.RowsExamined
+		d.RowsSent += entry.RowsSent
+		d.times = append(d.times, entry.QueryTime)
+		// 保留最慢的一次作为示例
+		if entry.QueryTime >= d.Max {
+			d.Max = entry.QueryTime
+			d.Sample = strings.TrimSuffix(query, ";")
+			if entry.DB != "" {
+				d.DB = entry.DB
+			}
+		}
+	}
+
+	reader := bufio.NewReaderSize(r, 1<<20)
+	entry := &slowEntry{}
+	db := ""
+	inQuery := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "# "):
+			// 新记录的头部，之前的语句结束
+			if inQuery {
+				add(entry)
+				entry = &slowEntry{DB: db}
+				inQuery = false
+			}
+			if match := slowUserHost.FindStringSubmatch(trimmed); match != nil {
+				entry.User = match[1]
+			}
+			for _, match := range slowMetric.FindAllStringSubmatch(trimmed, -1) {
+				switch match[1] {
+				case "Query_time":
+					entry.QueryTime, _ = strconv.ParseFloat(match[2], 64)
+				case "Lock_time":
+					entry.LockTime, _ = strconv.ParseFloat(match[2], 64)
+				case "Rows_sent":
+					entry.RowsSent, _ = strconv.ParseInt(match[2], 10, 64)
+				case "Rows_examined":
+					entry.RowsExamined, _ = strconv.ParseInt(match[2], 10, 64)
+				}
+			}
+		case trimmed == "" || slowSkip.MatchString(trimmed):
+		case !inQuery && slowUse.MatchString(trimmed):
+			db = slowUse.FindStringSubmatch(trimmed)[1]
+			entry.DB = db
+		default:
+			entry.Query += line
+			inQuery = true
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	if inQuery {
+		add(entry)
+	}
+
+	for _, d := range digests {
+		d.Average = d.Total / float64(d.Count)
+		d.P95 = percentile(d.times, 0.95)
+	}
+
+	return digests, count, nil
+}
+
+// percentile 最近秩法计算百分位数
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+func sortDigests(digests []*slowDigest, order string) error {
+	var key func(d *slowDigest) float64
+	switch order {
+	case "total":
+		key = func(d *slowDigest) float64 { return d.Total }
+	case "count":
+		key = func(d *slowDigest) float64 { return float64(d.Count) }
+	case "avg":
+		key = func(d *slowDigest) float64 { return d.Average }
+	case "p95":
+		key = func(d *slowDigest) float64 { return d.P95 }
+	case "rows":
+		key = func(d *slowDigest) float64 { return float64(d.RowsExamined) }
+	default:
+		return fmt.Errorf("不支持的排序：%s，可选 total、count、avg、p95、rows", order)
+	}
+
+	sort.SliceStable(digests, func(i, j int) bool { return key(digests[i]) > key(digests[j]) })
+
+	return nil
+}
+
+var (
+	fingerprintComment = regexp.MustCompile(`(?s)/\*[^!].*?\*/|(--|#)[^\n]*`)
+	fingerprintNumber  = regexp.MustCompile(`\b(0x[0-9a-f]+|[+-]?\d+(\.\d+)?(e[+-]?\d+)?)\b`)
+	fingerprintList    = regexp.MustCompile(`\(\s*\?(\s*,\s*\?)*\s*\)`)
+	fingerprintValues  = regexp.MustCompile(`(?i)\b(values?)\s*\(\?\+?\)(\s*,\s*\(\?\+?\))+`)
+)
+
+// fingerprintQuery 字符串及数字替换为 ?，去掉注释，IN 列表及多行 VALUES 合并，统一小写和空白
+func fingerprintQuery(query string) string {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+
+	// 先替换引号中的字符串，处理转义及连续两个引号，避免字符串中的 # 被当作注释
+	var b strings.Builder
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != '\'' && r != '"' {
+			b.WriteRune(r)
+			continue
+		}
+		for i++; i < len(runes); i++ {
+			if runes[i] == '\\' {
+				i++
+				continue
+			}
+			if runes[i] == r {
+				if i+1 < len(runes) && runes[i+1] == r {
+					i++
+					continue
+				}
+				break
+			}
+		}
+		b.WriteString("?")
+	}
+
+	s := fingerprintComment.ReplaceAllString(b.String(), "")
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = fingerprintNumber.ReplaceAllString(s, "?")
+	s = fingerprintList.ReplaceAllString(s, "(?+)")
+	s = fingerprintValues.ReplaceAllString(s, "$1 (?+)")
+
+	return s
+}
+
+func printDigests(digests []*slowDigest, total int) {
+	color.Bold.Println(fmt.Sprintf("\r\n共 %d 条慢查询\r\n", total))
+
+	result := &resultSet{
+		Columns: []string{"排名", "次数", "总时间", "平均", "p95", "最长", "扫描行", "返回行", "数据库", "指纹"},
+		Numeric: []bool{true, true, true, true, true, true, true, true, false, false},
+	}
+	seconds := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) + "s" }
+	for i, d := range digests {
+		result.Rows = append(result.Rows, cells(strconv.Itoa(i+1), strconv.Itoa(d.Count), seconds(d.Total), seconds(d.Average),
+			seconds(d.P95), seconds(d.Max), strconv.FormatInt(d.RowsExamined, 10), strconv.FormatInt(d.RowsSent, 10), d.DB, abbreviate(d.Fingerprint, 80)))
+	}
+	result.writeTable(os.Stdout)
+
+	for i, d := range digests {
+		color.Info.Println(fmt.Sprintf("\r\n#%d %s", i+1, d.Fingerprint))
+		fmt.Println("示例：" + abbreviate(oneLine(d.Sample), 500))
+	}
+}
+
+// explainDigests 对排名靠前的查询示例执行 EXPLAIN，只处理查询语句
+func explainDigests(cmd *cobra.Command, digests []*slowDigest) error {
+	cfg, err := resolveConfig(cmd)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, color.Info.Render("地址："+cfg.String()))
+
+	ctx := context.Background()
+	conn, closeAll, err := session(cmd, ctx, "")
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	for i, d := range digests {
+		color.Bold.Println(fmt.Sprintf("\r\nEXPLAIN #%d", i+1))
+		if keyword := firstKeyword(d.Sample); keyword != "SELECT" && keyword != "WITH" {
+			color.Warnln("跳过 " + keyword + " 语句")
+			continue
+		}
+		if d.DB != "" {
+			if _, err := conn.ExecContext(ctx, "USE "+quoteIdent(d.DB)); err != nil {
+				color.Warnln(err.Error())
+				continue
+			}
+		}
+
+		result, _, err := execute(ctx, conn, "EXPLAIN "+d.Sample)
+		if err != nil {
+			color.Warnln(err.Error())
+			continue
+		}
+		result.writeTable(os.Stdout)
+	}
+
+	return nil
+}
+
+func init() {
+	slowlog.Flags().Int("top", 10, "输出排名靠前的指纹数量，0 表示全部")
+	slowlog.Flags().String("sort", "total", "排序：total、count、avg、p95、rows")
+	slowlog.Flags().Int("explain", 0, "对排名前N的查询执行 EXPLAIN，需要连接数据库")
+	slowlog.Flags().Bool("json", false, "输出JSON")
+}
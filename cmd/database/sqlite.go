@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// errNoUsers SQLite 没有账号，访问权限由文件权限控制
+var errNoUsers = errors.New("SQLite 没有账号管理，访问权限由数据库文件的权限控制")
+
+// sqliteDialect SQLite，数据库即文件，地址为 sqlite://path/to/app.db 或 sqlite:///abs/path/app.db
+type sqliteDialect struct{}
+
+func (sqliteDialect) DefaultPort() int {
+	return 0
+}
+
+// Open 以读写方式打开已存在的文件，避免地址写错时生成空的数据库
+func (sqliteDialect) Open(c Config) (*sql.DB, error) {
+	if c.Database == "" {
+		return nil, errors.New("请指定 SQLite 数据库文件，例如 sqlite://data/app.db")
+	}
+	if _, err := os.Stat(c.Database); err != nil {
+		return nil, fmt.Errorf("无法打开 SQLite 数据库文件：%s", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+c.Database+"?mode=rw&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if err := ping(db, c); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// QuoteIdent 双引号，内部的双引号写两次
+func (sqliteDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteString 单引号写两次，SQLite 的字符串中反斜杠没有特殊含义
+func (sqliteDialect) QuoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// ListDatabases 当前连接中的库，main 及附加的库，后面为对应的文件
+func (sqliteDialect) ListDatabases(db *sql.DB) ([]string, error) {
+	var names []string
+	err := eachRow(db, "PRAGMA database_list", nil, func(rows *sql.Rows) error {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return err
+		}
+		if file != "" {
+			name += "  " + file
+		}
+		names = append(names, name)
+		return nil
+	})
+
+	return names, err
+}
+
+// CreateDatabase 在当前数据库文件所在的目录中创建新的数据库文件，没有扩展名时使用 .db
+func (sqliteDialect) CreateDatabase(db *sql.DB, c Config, name string, opts createOptions) error {
+	if filepath.Ext(name) == "" {
+		name += ".db"
+	}
+	path := filepath.Join(filepath.Dir(c.Database), name)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	created, err := sql.Open("sqlite", "file:"+path+"?mode=rwc")
+	if err != nil {
+		return err
+	}
+	defer created.Close()
+
+	// 写入文件头，空文件同样是合法的数据库，这里确保文件已经生成
+	_, err = created.Exec("PRAGMA user_version = 0; VACUUM")
+
+	return err
+}
+
+// TableStats 行数为实际统计，大小需要 dbstat 虚拟表支持，不支持时为0
+func (d sqliteDialect) TableStats(db *sql.DB, schema string) ([]tableStat, error) {
+	if schema == "" {
+		schema = "main"
+	}
+	prefix := d.QuoteIdent(schema) + "."
+
+	var tables []tableStat
+	err := eachRow(db, "SELECT name FROM "+prefix+"sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\'", nil, func(rows *sql.Rows) error {
+		t := tableStat{Schema: schema, Engine: "sqlite"}
+		if err := rows.Scan(&t.Name); err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 表及索引占用的页，索引计入所属的表
+	data, index := map[string]int64{}, map[string]int64{}
+	eachRow(db, `SELECT m.tbl_name, m.type, SUM(s.pgsize) FROM dbstat(?) s JOIN `+prefix+`sqlite_master m ON m.name = s.name GROUP BY m.tbl_name, m.type`,
+		[]interface{}{schema}, func(rows *sql.Rows) error {
+			var table, typ string
+			var size int64
+			if err := rows.Scan(&table, &typ, &size); err != nil {
+				return err
+			}
+			if typ == "index" {
+				index[table] += size
+			} else {
+				data[table] += size
+			}
+			return nil
+		})
+
+	for i := range tables {
+		t := &tables[i]
+		t.Data, t.Index = data[t.Name], index[t.Name]
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + prefix + d.QuoteIdent(t.Name)).Scan(&t.Rows); err != nil {
+			return nil, err
+		}
+		err := eachRow(db, "SELECT pk FROM pragma_table_info(?, ?)", []interface{}{t.Name, schema}, func(rows *sql.Rows) error {
+			var pk int
+			if err := rows.Scan(&pk); err != nil {
+				return err
+			}
+			if pk > 0 {
+				t.PrimaryKey = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tables, nil
+}
+
+func (sqliteDialect) CreateUser(db *sql.DB, name, host, password string) error {
+	return errNoUsers
+}
+
+func (sqliteDialect) DropUser(db *sql.DB, name, host string) error {
+	return errNoUsers
+}
+
+func (sqliteDialect) SetPassword(db *sql.DB, name, host, password string) error {
+	return errNoUsers
+}
+
+func (sqliteDialect) ListUsers(db *sql.DB) ([]string, error) {
+	return nil, errNoUsers
+}
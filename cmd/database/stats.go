@@ -0,0 +1,198 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"jarvis/cmd/shared"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// 可回收空间超过表大小的20%且不少于10MB时视为碎片较多
+const (
+	fragmentRatio = 0.2
+	fragmentMin   = 10 << 20
+)
+
+type tableStat struct {
+	Schema     string `json:"schema"`
+	Name       string `json:"name"`
+	Engine     string `json:"engine"`
+	Rows       int64  `json:"rows"`
+	Data       int64  `json:"data_bytes"`
+	Index      int64  `json:"index_bytes"`
+	Free       int64  `json:"free_bytes"`
+	PrimaryKey bool   `json:"primary_key"`
+}
+
+func (t tableStat) Size() int64 {
+	return t.Data + t.Index
+}
+
+func (t tableStat) Fragmented() bool {
+	return t.Free >= fragmentMin && float64(t.Free) > fragmentRatio*float64(t.Size())
+}
+
+type schemaStat struct {
+	Name   string `json:"name"`
+	Tables int    `json:"tables"`
+	Rows   int64  `json:"rows"`
+	Data   int64  `json:"data_bytes"`
+	Index  int64  `json:"index_bytes"`
+	Free   int64  `json:"free_bytes"`
+}
+
+type statsReport struct {
+	Schemas      []schemaStat `json:"schemas"`
+	Largest      []tableStat  `json:"largest_tables"`
+	Fragmented   []tableStat  `json:"fragmented"`
+	NoPrimaryKey []tableStat  `json:"no_primary_key"`
+	MyISAM       []tableStat  `json:"myisam"`
+}
+
+var stats = &cobra.Command{
+	Use:   "stats",
+	Short: "统计数据库及表的大小",
+	Long:  color.Success.Render("\r\n统计每个库的大小、估算行数、数据与索引大小及最大的表，\r\n并列出碎片较多、没有主键及使用 MyISAM 的表"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("db")
+		top, _ := cmd.Flags().GetInt("top")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		db, _, d, err := connectAny(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		tables, err := d.TableStats(db, name)
+		if err != nil {
+			return err
+		}
+
+		report := buildStats(tables, top)
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+
+		return printStats(report)
+	},
+}
+
+// buildStats 按库汇总，并找出最大的表及有问题的表
+func buildStats(tables []tableStat, top int) statsReport {
+	report := statsReport{Schemas: []schemaStat{}, Largest: []tableStat{}, Fragmented: []tableStat{}, NoPrimaryKey: []tableStat{}, MyISAM: []tableStat{}}
+
+	schemas := map[string]*schemaStat{}
+	for _, t := range tables {
+		s := schemas[t.Schema]
+		if s == nil {
+			s = &schemaStat{Name: t.Schema}
+			schemas[t.Schema] = s
+		}
+		s.Tables++
+		s.Rows += t.Rows
+		s.Data += t.Data
+		s.Index += t.Index
+		s.Free += t.Free
+
+		if t.Fragmented() {
+			report.Fragmented = append(report.Fragmented, t)
+		}
+		if !t.PrimaryKey {
+			report.NoPrimaryKey = append(report.NoPrimaryKey, t)
+		}
+		if strings.EqualFold(t.Engine, "MyISAM") {
+			report.MyISAM = append(report.MyISAM, t)
+		}
+	}
+
+	for _, s := range schemas {
+		report.Schemas = append(report.Schemas, *s)
+	}
+	sort.Slice(report.Schemas, func(i, j int) bool {
+		return report.Schemas[i].Data+report.Schemas[i].Index > report.Schemas[j].Data+report.Schemas[j].Index
+	})
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Size() > tables[j].Size() })
+	if top > 0 && len(tables) > top {
+		report.Largest = append(report.Largest, tables[:top]...)
+	} else {
+		report.Largest = append(report.Largest, tables...)
+	}
+	sort.Slice(report.Fragmented, func(i, j int) bool { return report.Fragmented[i].Free > report.Fragmented[j].Free })
+
+	return report
+}
+
+func printStats(report statsReport) error {
+	schemas := &resultSet{
+		Columns: []string{"数据库", "表", "估算行数", "数据", "索引", "可回收", "总大小"},
+		Numeric: []bool{false, true, true, true, true, true, true},
+	}
+	for _, s := range report.Schemas {
+		schemas.Rows = append(schemas.Rows, cells(s.Name, strconv.Itoa(s.Tables), strconv.FormatInt(s.Rows, 10),
+			shared.FormatBytes(s.Data), shared.FormatBytes(s.Index), shared.FormatBytes(s.Free), shared.FormatBytes(s.Data+s.Index)))
+	}
+	color.Bold.Println("\r\n数据库：")
+	if err := schemas.writeTable(os.Stdout); err != nil {
+		return err
+	}
+
+	largest := &resultSet{
+		Columns: []string{"表", "引擎", "估算行数", "数据", "索引", "总大小"},
+		Numeric: []bool{false, false, true, true, true, true},
+	}
+	for _, t := range report.Largest {
+		largest.Rows = append(largest.Rows, cells(t.Schema+"."+t.Name, t.Engine, strconv.FormatInt(t.Rows, 10),
+			shared.FormatBytes(t.Data), shared.FormatBytes(t.Index), shared.FormatBytes(t.Size())))
+	}
+	color.Bold.Println("\r\n最大的表：")
+	if err := largest.writeTable(os.Stdout); err != nil {
+		return err
+	}
+
+	if len(report.Fragmented) > 0 {
+		color.Warnln("\r\n碎片较多的表，可以在低峰期执行 OPTIMIZE TABLE：")
+		for _, t := range report.Fragmented {
+			fmt.Printf("  %s  可回收 %s / 总大小 %s\n", t.Schema+"."+t.Name, shared.FormatBytes(t.Free), shared.FormatBytes(t.Size()))
+		}
+	}
+	if len(report.NoPrimaryKey) > 0 {
+		color.Warnln("\r\n没有主键的表：")
+		for _, t := range report.NoPrimaryKey {
+			fmt.Println("  " + t.Schema + "." + t.Name)
+		}
+	}
+	if len(report.MyISAM) > 0 {
+		color.Warnln("\r\n使用 MyISAM 的表，不支持事务且崩溃后可能损坏：")
+		for _, t := range report.MyISAM {
+			fmt.Println("  " + t.Schema + "." + t.Name)
+		}
+	}
+
+	return nil
+}
+
+// cells 将字符串转换为结果行
+func cells(values ...string) []*string {
+	row := make([]*string, len(values))
+	for i := range values {
+		row[i] = &values[i]
+	}
+
+	return row
+}
+
+func init() {
+	stats.Flags().String("db", "", "只统计指定的库，PostgreSQL 及 SQLite 为 schema")
+	stats.Flags().Int("top", 10, "列出最大的表的数量")
+	stats.Flags().Bool("json", false, "输出JSON")
+}
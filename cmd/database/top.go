@@ -0,0 +1,223 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// 不是客户端的后台线程
+var backgroundUsers = map[string]bool{
+	"system user":     true,
+	"event_scheduler": true,
+}
+
+type process struct {
+	ID      int64
+	User    string
+	Host    string
+	DB      string
+	Command string
+	Time    int64
+	State   string
+	Info    string
+	// BlockedBy 等待行锁时持有锁的线程
+	BlockedBy []int64
+}
+
+// Client 去掉端口的来源主机
+func (p process) Client() string {
+	if i := strings.LastIndex(p.Host, ":"); i > 0 {
+		return p.Host[:i]
+	}
+
+	return p.Host
+}
+
+// Waiting 是否在等待行锁或元数据锁
+func (p process) Waiting() bool {
+	return len(p.BlockedBy) > 0 || strings.Contains(strings.ToLower(p.State), "lock")
+}
+
+var top = &cobra.Command{
+	Use:   "top",
+	Short: "实时查看正在执行的语句",
+	Long:  color.Success.Render("\r\n定时刷新 processlist，按执行时间排序并按用户、来源及数据库汇总，高亮等待锁的线程"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		once, _ := cmd.Flags().GetBool("once")
+		all, _ := cmd.Flags().GetBool("all")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		db, _, err := connect(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		for {
+			processes, err := listProcesses(db, all)
+			if err != nil {
+				return err
+			}
+
+			if !once {
+				// 清屏并回到左上角
+				fmt.Print("\033[H\033[2J")
+			}
+			color.Bold.Println(fmt.Sprintf("%s  线程 %d", time.Now().Format("15:04:05"), len(processes)))
+			printGroups(processes)
+			printProcesses(processes, limit)
+
+			if once {
+				return nil
+			}
+			time.Sleep(interval)
+		}
+	},
+}
+
+// listProcesses 读取 processlist，按执行时间从长到短排序，all为false时忽略空闲连接
+func listProcesses(db *sql.DB, all bool) ([]process, error) {
+	var self int64
+	if err := db.QueryRow("SELECT CONNECTION_ID()").Scan(&self); err != nil {
+		return nil, err
+	}
+
+	blockers := lockWaits(db)
+	var processes []process
+	err := eachRow(db, `SELECT ID, IFNULL(USER, ''), IFNULL(HOST, ''), IFNULL(DB, ''), IFNULL(COMMAND, ''), IFNULL(TIME, 0), IFNULL(STATE, ''), IFNULL(INFO, '')
+		FROM information_schema.PROCESSLIST`, nil, func(rows *sql.Rows) error {
+		p := process{}
+		if err := rows.Scan(&p.ID, &p.User, &p.Host, &p.DB, &p.Command, &p.Time, &p.State, &p.Info); err != nil {
+			return err
+		}
+		if p.ID == self || backgroundUsers[p.User] || p.Command == "Daemon" || p.Command == "Binlog Dump" {
+			return nil
+		}
+		if !all && p.Command == "Sleep" {
+			return nil
+		}
+		p.BlockedBy = blockers[p.ID]
+		processes = append(processes, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(processes, func(i, j int) bool { return processes[i].Time > processes[j].Time })
+
+	return processes, nil
+}
+
+// lockWaits 等待行锁的线程及持有锁的线程，依次尝试 sys 库（MySQL 8.0、5.7）和 information_schema（5.6），没有权限时返回空
+func lockWaits(db *sql.DB) map[int64][]int64 {
+	queries := []string{
+		"SELECT waiting_pid, blocking_pid FROM sys.innodb_lock_waits",
+		`SELECT r.trx_mysql_thread_id, b.trx_mysql_thread_id FROM information_schema.INNODB_LOCK_WAITS w
+			JOIN information_schema.INNODB_TRX r ON r.trx_id = w.requesting_trx_id
+			JOIN information_schema.INNODB_TRX b ON b.trx_id = w.blocking_trx_id`,
+	}
+
+	for _, query := range queries {
+		waits := map[int64][]int64{}
+		err := eachRow(db, query, nil, func(rows *sql.Rows) error {
+			var waiting, blocking int64
+			if err := rows.Scan(&waiting, &blocking); err != nil {
+				return err
+			}
+			waits[waiting] = append(waits[waiting], blocking)
+			return nil
+		})
+		if err == nil {
+			return waits
+		}
+	}
+
+	return map[int64][]int64{}
+}
+
+// printGroups 按用户、来源主机及数据库汇总线程数及最长执行时间
+func printGroups(processes []process) {
+	type group struct {
+		user, client, db string
+		count, waiting   int
+		longest          int64
+	}
+
+	groups := map[string]*group{}
+	var keys []string
+	for _, p := range processes {
+		key := p.User + "\x00" + p.Client() + "\x00" + p.DB
+		g := groups[key]
+		if g == nil {
+			g = &group{user: p.User, client: p.Client(), db: p.DB}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.count++
+		if p.Waiting() {
+			g.waiting++
+		}
+		if p.Time > g.longest {
+			g.longest = p.Time
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return groups[keys[i]].count > groups[keys[j]].count })
+
+	result := &resultSet{
+		Columns: []string{"用户", "来源", "数据库", "线程", "等待锁", "最长(秒)"},
+		Numeric: []bool{false, false, false, true, true, true},
+	}
+	for _, key := range keys {
+		g := groups[key]
+		result.Rows = append(result.Rows, cells(g.user, g.client, g.db, strconv.Itoa(g.count), strconv.Itoa(g.waiting), strconv.FormatInt(g.longest, 10)))
+	}
+	result.writeTable(os.Stdout)
+}
+
+// printProcesses 输出线程列表，等待锁的线程标红并注明持有锁的线程
+func printProcesses(processes []process, limit int) {
+	fmt.Println()
+	for i, p := range processes {
+		if limit > 0 && i >= limit {
+			color.Gray.Println(fmt.Sprintf("... 还有 %d 个线程", len(processes)-limit))
+			break
+		}
+
+		line := fmt.Sprintf("%-8d %-16s %-20s %-16s %-8s %6ds  %s", p.ID, p.User, p.Client(), p.DB, p.Command, p.Time, abbreviate(oneLine(p.Info), 120))
+		switch {
+		case len(p.BlockedBy) > 0:
+			ids := make([]string, len(p.BlockedBy))
+			for i, id := range p.BlockedBy {
+				ids[i] = strconv.FormatInt(id, 10)
+			}
+			color.Error.Println(line + "  [等待线程 " + strings.Join(ids, ",") + " 的行锁]")
+		case p.Waiting():
+			color.Error.Println(line + "  [" + p.State + "]")
+		case p.Time >= 10:
+			color.Warn.Println(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// oneLine 合并语句中的连续空白
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func init() {
+	top.Flags().Duration("interval", 2*time.Second, "刷新间隔")
+	top.Flags().Bool("once", false, "只输出一次")
+	top.Flags().Bool("all", false, "包括空闲的连接")
+	top.Flags().Int("limit", 30, "最多显示的线程数，0 表示全部")
+}
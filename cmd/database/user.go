@@ -0,0 +1,184 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"jarvis/cmd/shared"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var user = &cobra.Command{
+	Use:   "user",
+	Short: "管理数据库账号",
+	Long:  color.Success.Render("\r\n创建、删除、修改密码及列出数据库账号"),
+}
+
+var userCreate = &cobra.Command{
+	Use:   "create <name>",
+	Short: "创建账号",
+	Long:  color.Success.Render("\r\n创建账号，可同时按预设授予某个数据库的权限。不指定密码时在终端中输入，或随机生成"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("user-host")
+		preset, _ := cmd.Flags().GetString("grant")
+		dbName, _ := cmd.Flags().GetString("db")
+
+		if _, ok := presets[preset]; preset != "" && !ok {
+			return fmt.Errorf("不支持的权限预设：%s，可选 %s", preset, strings.Join(presetNames(), "、"))
+		}
+		if preset != "" && dbName == "" {
+			return errors.New("授权时请通过 --db 指定数据库")
+		}
+
+		db, cfg, d, err := connectAny(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if cfg.Driver == "sqlite" {
+			return errNoUsers
+		}
+		if preset != "" && cfg.Driver != "mysql" {
+			return errors.New("权限预设目前只支持 MySQL")
+		}
+
+		password, generated, err := newPassword(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := d.CreateUser(db, args[0], from, password); err != nil {
+			return fmt.Errorf("创建账号失败：%s", err)
+		}
+		color.Infoln("已创建账号：" + accountName(cfg, args[0], from))
+
+		if preset != "" {
+			if err := grantPreset(db, preset, dbName, args[0], from); err != nil {
+				return err
+			}
+			color.Infoln("已授予 " + preset + " 权限：" + dbName)
+		}
+
+		if generated {
+			color.Warnln("随机生成的密码（只显示这一次）：" + password)
+		}
+
+		return nil
+	},
+}
+
+var userDrop = &cobra.Command{
+	Use:   "drop <name>",
+	Short: "删除账号",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("user-host")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		db, cfg, d, err := connectAny(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if cfg.Driver == "sqlite" {
+			return errNoUsers
+		}
+		if !yes && !shared.Confirm("确定删除账号 "+accountName(cfg, args[0], from)+"？") {
+			return nil
+		}
+
+		if err := d.DropUser(db, args[0], from); err != nil {
+			return fmt.Errorf("删除账号失败：%s", err)
+		}
+		color.Infoln("已删除账号：" + accountName(cfg, args[0], from))
+
+		return nil
+	},
+}
+
+var userPasswd = &cobra.Command{
+	Use:   "passwd <name>",
+	Short: "修改账号密码",
+	Long:  color.Success.Render("\r\n修改账号密码，新密码在终端中输入，或随机生成"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("user-host")
+
+		db, cfg, d, err := connectAny(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if cfg.Driver == "sqlite" {
+			return errNoUsers
+		}
+
+		password, generated, err := newPassword(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := d.SetPassword(db, args[0], from, password); err != nil {
+			return fmt.Errorf("修改密码失败：%s", err)
+		}
+		color.Infoln("已修改密码：" + accountName(cfg, args[0], from))
+
+		if generated {
+			color.Warnln("随机生成的密码（只显示这一次）：" + password)
+		}
+
+		return nil
+	},
+}
+
+var userList = &cobra.Command{
+	Use:   "list",
+	Short: "列出账号",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, d, err := connectAny(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		users, err := d.ListUsers(db)
+		if err != nil {
+			return fmt.Errorf("查询账号失败：%s", err)
+		}
+
+		color.Infoln("账号列表：")
+		for _, name := range users {
+			color.Println("  " + name)
+		}
+
+		return nil
+	},
+}
+
+// accountName 展示用的账号，只有 MySQL 的账号区分来源主机
+func accountName(cfg Config, name, host string) string {
+	if cfg.Driver != "mysql" {
+		return name
+	}
+
+	return name + "@" + host
+}
+
+func init() {
+	user.AddCommand(userCreate)
+	user.AddCommand(userDrop)
+	user.AddCommand(userPasswd)
+	user.AddCommand(userList)
+	for _, c := range []*cobra.Command{userCreate, userDrop, userPasswd} {
+		c.Flags().String("user-host", "%", "账号允许登录的来源主机，只用于 MySQL")
+	}
+	userCreate.Flags().String("grant", "", "创建后授予的权限预设：readonly、readwrite、admin")
+	userCreate.Flags().String("db", "", "授权的数据库，* 表示全部")
+	userDrop.Flags().BoolP("yes", "y", false, "不询问直接删除")
+}